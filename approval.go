@@ -0,0 +1,63 @@
+package gotcc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xiaoxuxiansheng/gotcc/log"
+)
+
+// 审批一笔待审批的事务，approve 为 true 时 confirm，否则 cancel. 重复审批时幂等.
+// 与已有审批结果相反，或者事务不处于待审批状态时报错；审批已超时但尚未被轮询任务 cancel 的事务仍然可以驳回.
+// 审批结果先于第二阶段记录到事务日志中，与轮询任务的超时 cancel 以先记录者为准，第二阶段未执行完成时由轮询任务按照审批结果继续推进
+func (t *TXManager) ApproveTX(ctx context.Context, txID string, approve bool) error {
+	tx, err := t.txStore.GetTX(ctx, txID)
+	if err != nil {
+		return err
+	}
+	ctx = log.WithTXID(ctx, txID)
+
+	txStatus := tx.getStatus(time.Now(), t.opts.Timeout)
+	switch {
+	case tx.ApprovalTimeout <= 0:
+		return fmt.Errorf("%w: tx: %s does not require approval", ErrStoreConflict, txID)
+	case tx.Status == TXSuccessful && approve, tx.Status == TXFailure && !approve:
+		return nil
+	case tx.Status.finished():
+		return fmt.Errorf("%w: tx: %s is already %s", ErrStoreConflict, txID, tx.Status)
+	// 已经记录了审批结果的，结果相同时继续执行第二阶段
+	case tx.Status == TXDeciding:
+	case txStatus == TXFailure && !approve:
+	case txStatus != TXAwaitingApproval:
+		return fmt.Errorf("%w: tx: %s is %s, not awaiting approval", ErrStoreConflict, txID, txStatus)
+	}
+
+	if err = t.decideTX(ctx, tx, approve); err != nil {
+		return err
+	}
+	txStatus = TXFailure
+	if approve {
+		txStatus = TXSuccessful
+	}
+	t.opts.Metrics.TXFinished(txStatus, time.Since(tx.CreatedAt))
+	return nil
+}
+
+// 把 try 全部成功的审批事务置为待审批状态，并以当前时间加上审批时长作为审批截止时间
+func (t *TXManager) awaitApproval(ctx context.Context, tx *Transaction) error {
+	if tx.Status == TXAwaitingApproval {
+		return nil
+	}
+	approvalStore, ok := t.txStore.(TXApprovalStore)
+	if !ok {
+		return fmt.Errorf("%w: txStore does not implement TXApprovalStore, approval is not supported", ErrStoreUnsupported)
+	}
+	deadline := time.Now().Add(tx.ApprovalTimeout)
+	if err := approvalStore.TXAwaitApproval(ctx, tx.TXID, deadline); err != nil {
+		return err
+	}
+	tx.Status, tx.ApprovalDeadline = TXAwaitingApproval, deadline
+	t.log(ctx, log.LevelInfo, "tx awaiting approval")
+	return nil
+}
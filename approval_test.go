@@ -0,0 +1,147 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_txManager_approval(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// 为 true 时不审批，等待审批超时
+		expire  bool
+		approve bool
+		status  TXStatus
+		calls   []string
+	}{
+		{
+			name:    "approve",
+			timeout: time.Minute,
+			approve: true,
+			status:  TXSuccessful,
+			calls:   []string{"a:try", "a:confirm"},
+		},
+		{
+			name:    "reject",
+			timeout: time.Minute,
+			status:  TXFailure,
+			calls:   []string{"a:try", "a:cancel"},
+		},
+		{
+			// 超过审批截止时间后由轮询任务自动 cancel
+			name:    "timeout",
+			timeout: 5 * time.Millisecond,
+			expire:  true,
+			status:  TXFailure,
+			calls:   []string{"a:try", "a:cancel"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+			defer txManager.Stop()
+			var r recorder
+			assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("a"), recorder: &r}))
+			ctx := context.Background()
+
+			// try 全部成功后在事务日志中处于待审批状态，轮询任务不会推进
+			result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithApproval(tt.timeout))
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, result.Success)
+			assert.Equal(t, TXAwaitingApproval, result.Status)
+			assert.Equal(t, false, result.Phase2Done)
+			tx, err := txManager.GetTX(ctx, result.TXID)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, TXAwaitingApproval, tx.Status)
+			assert.Equal(t, false, tx.ApprovalDeadline.IsZero())
+			txStatus, err := txManager.advanceProgress(ctx, tx)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, TXAwaitingApproval, txStatus)
+			assert.Equal(t, []string{"a:try"}, r.calls)
+
+			if tt.expire {
+				<-time.After(2 * tt.timeout)
+				txs, err := txManager.txStore.GetHangingTXs(ctx)
+				assert.Equal(t, nil, err)
+				assert.Equal(t, nil, txManager.batchAdvanceProgress(txs))
+				assert.Equal(t, true, errors.Is(txManager.ApproveTX(ctx, result.TXID, true), ErrStoreConflict))
+			} else {
+				assert.Equal(t, nil, txManager.ApproveTX(ctx, result.TXID, tt.approve))
+				// 重复审批幂等，与已有审批结果相反时报错
+				assert.Equal(t, nil, txManager.ApproveTX(ctx, result.TXID, tt.approve))
+				assert.Equal(t, true, errors.Is(txManager.ApproveTX(ctx, result.TXID, !tt.approve), ErrStoreConflict))
+			}
+
+			tx, err = txManager.GetTX(ctx, result.TXID)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.status, tx.Status)
+			assert.Equal(t, tt.calls, r.calls)
+		})
+	}
+}
+
+func Test_txManager_approval_decision(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore, WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var r recorder
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("a"), recorder: &r}))
+	ctx := context.Background()
+	advance := func() {
+		txs, err := txStore.GetHangingTXs(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, txManager.batchAdvanceProgress(txs))
+	}
+
+	// 审批通过的结果已经记录但第二阶段尚未执行，超过审批截止时间后轮询任务仍然按照审批结果 confirm
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithApproval(20*time.Millisecond))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, txStore.(TXDecisionStore).TXDecide(ctx, result.TXID, true))
+	<-time.After(40 * time.Millisecond)
+	advance()
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXSuccessful, tx.Status)
+	assert.Equal(t, []string{"a:try", "a:confirm"}, r.calls)
+
+	// 轮询任务已经记录了超时 cancel 的决议，随后到达的审批通过被拒绝，不会 confirm
+	r.calls = nil
+	result, err = txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithApproval(20*time.Millisecond))
+	assert.Equal(t, nil, err)
+	<-time.After(40 * time.Millisecond)
+	assert.Equal(t, nil, txStore.(TXDecisionStore).TXDecide(ctx, result.TXID, false))
+	assert.Equal(t, true, errors.Is(txManager.ApproveTX(ctx, result.TXID, true), ErrStoreConflict))
+	assert.Equal(t, []string{"a:try"}, r.calls)
+	// 重复驳回时按照已记录的决议完成 cancel
+	assert.Equal(t, nil, txManager.ApproveTX(ctx, result.TXID, false))
+	tx, err = txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXFailure, tx.Status)
+	assert.Equal(t, []string{"a:try", "a:cancel"}, r.calls)
+}
+
+func Test_txManager_approval_invalid(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore())
+	defer txManager.Stop()
+	assert.Equal(t, nil, txManager.Register(newMockComponent("a")))
+	ctx := context.Background()
+
+	// 不需要审批的事务无法审批
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, errors.Is(txManager.ApproveTX(ctx, result.TXID, true), ErrStoreConflict))
+	assert.Equal(t, true, errors.Is(txManager.ApproveTX(ctx, "unknown", true), ErrTXNotFound))
+
+	// 基础的 TXStore 不支持审批
+	baseManager := NewTXManager(&baseTXStore{TXStore: newMockTXStore()})
+	defer baseManager.Stop()
+	assert.Equal(t, nil, baseManager.Register(newMockComponent("a")))
+	_, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithApproval(time.Minute))
+	assert.Equal(t, true, errors.Is(err, ErrStoreUnsupported))
+}
@@ -0,0 +1,85 @@
+package gotcc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// 请求参数等载荷的编解码方式
+type Codec interface {
+	// 编解码方式的名称，会随载荷一并持久化，解码时据此选择对应的 Codec
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// json 编解码，默认使用的编解码方式
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+var (
+	codecMux sync.RWMutex
+	codecs   = map[string]Codec{
+		JSONCodec{}.Name(): JSONCodec{},
+	}
+)
+
+// 注册编解码方式，用于解码持久化的载荷以及远端传输的请求
+func RegisterCodec(codec Codec) {
+	codecMux.Lock()
+	defer codecMux.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// 根据名称获取已注册的编解码方式
+func GetCodec(name string) (Codec, error) {
+	codecMux.RLock()
+	defer codecMux.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCodecNotFound, name)
+	}
+	return codec, nil
+}
+
+// 带有编解码方式和版本号的载荷，用于持久化到事务日志中
+type Payload struct {
+	// 编解码方式的名称
+	Codec string `json:"codec"`
+	// 载荷的版本号，对应组件请求参数 Schema 的版本
+	Version uint32 `json:"version"`
+	Data    []byte `json:"data"`
+}
+
+func EncodePayload(codec Codec, version uint32, v interface{}) (*Payload, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Payload{
+		Codec:   codec.Name(),
+		Version: version,
+		Data:    data,
+	}, nil
+}
+
+// 基于载荷记录的编解码方式进行解码
+func (p *Payload) Decode(v interface{}) error {
+	codec, err := GetCodec(p.Codec)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(p.Data, v)
+}
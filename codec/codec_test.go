@@ -0,0 +1,60 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+)
+
+func Test_Codec(t *testing.T) {
+	for _, codec := range []gotcc.Codec{gotcc.JSONCodec{}, MsgPack{}, Protobuf{}} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			registered, err := gotcc.GetCodec(codec.Name())
+			assert.Equal(t, nil, err)
+			assert.Equal(t, codec, registered)
+
+			req := gotcc.TCCReq{
+				ComponentID: "a",
+				TXID:        "1",
+				Data:        map[string]interface{}{"biz_id": "biz", "nested": map[string]interface{}{"ok": true}},
+			}
+			payload, err := gotcc.EncodePayload(codec, 2, &req)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, codec.Name(), payload.Codec)
+			assert.Equal(t, uint32(2), payload.Version)
+
+			var got gotcc.TCCReq
+			assert.Equal(t, nil, payload.Decode(&got))
+			assert.Equal(t, req, got)
+		})
+	}
+}
+
+func Test_Protobuf_message(t *testing.T) {
+	message, err := structpb.NewStruct(map[string]interface{}{"amount": float64(10)})
+	assert.Equal(t, nil, err)
+	body, err := Protobuf{}.Marshal(message)
+	assert.Equal(t, nil, err)
+
+	var got structpb.Struct
+	assert.Equal(t, nil, Protobuf{}.Unmarshal(body, &got))
+	assert.Equal(t, float64(10), got.AsMap()["amount"])
+
+	_, err = Protobuf{}.Marshal([]string{"a"})
+	assert.NotEqual(t, nil, err)
+}
+
+func Test_Protobuf_empty(t *testing.T) {
+	// 未指定请求参数时，nil map 与空 map 均编码为空的 Struct
+	for _, data := range []map[string]interface{}{nil, {}} {
+		body, err := Protobuf{}.Marshal(data)
+		assert.Equal(t, nil, err)
+
+		var got map[string]interface{}
+		assert.Equal(t, nil, Protobuf{}.Unmarshal(body, &got))
+		assert.Equal(t, 0, len(got))
+	}
+}
@@ -0,0 +1,39 @@
+// codec 提供 json 以外的载荷编解码方式，引入该包时会自动注册到 gotcc 中
+package codec
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+)
+
+func init() {
+	gotcc.RegisterCodec(MsgPack{})
+	gotcc.RegisterCodec(Protobuf{})
+}
+
+// messagepack 编解码，结构体字段名沿用 json tag
+type MsgPack struct{}
+
+func (MsgPack) Name() string {
+	return "msgpack"
+}
+
+func (MsgPack) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgPack) Unmarshal(data []byte, v interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
@@ -0,0 +1,53 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// protobuf 编解码. proto.Message 直接编解码，其余类型按照 json 语义转换为 google.protobuf.Struct 后编解码
+type Protobuf struct{}
+
+func (Protobuf) Name() string {
+	return "protobuf"
+}
+
+func (Protobuf) Marshal(v interface{}) ([]byte, error) {
+	if message, ok := v.(proto.Message); ok {
+		return proto.Marshal(message)
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var s structpb.Struct
+	// nil map 按照 json 语义为 null，视为空的 Struct
+	if string(body) == "null" {
+		return proto.Marshal(&s)
+	}
+	if err = protojson.Unmarshal(body, &s); err != nil {
+		return nil, fmt.Errorf("protobuf codec only supports proto.Message or json object, err: %w", err)
+	}
+	return proto.Marshal(&s)
+}
+
+func (Protobuf) Unmarshal(data []byte, v interface{}) error {
+	if message, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, message)
+	}
+
+	var s structpb.Struct
+	if err := proto.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	body, err := protojson.Marshal(&s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
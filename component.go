@@ -8,6 +8,8 @@ type TCCReq struct {
 	ComponentID string                 `json:"componentID"`
 	TXID        string                 `json:"txID"`
 	Data        map[string]interface{} `json:"data"`
+	// 透传给组件的元数据，例如链路追踪信息
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // tcc 响应结果
@@ -15,6 +17,21 @@ type TCCResp struct {
 	ComponentID string `json:"componentID"`
 	ACK         bool   `json:"ack"`
 	TXID        string `json:"txID"`
+	// 响应结果，可选. 例如 try 预留资源的单号，可用于构造依赖组件的请求参数
+	Result map[string]interface{} `json:"result,omitempty"`
+	// 拒绝原因，可选. 仅在 ACK 为 false 时有意义
+	Reason *RejectReason `json:"reason,omitempty"`
+	// try 是否仍在异步处理中，可选. 为 true 时忽略 ACK，组件的 try 保持 hanging 状态，事务不等待其结果直接返回，
+	// 直到参与方通过 TXManager.ReportTry 回调结果，或者超过事务的截止时间后视为失败. 依赖于该组件的组件在回调成功后续跑.
+	// 超时后参与方可能先于回调收到 cancel 请求，需要支持空回滚
+	Pending bool `json:"pending,omitempty"`
+}
+
+// 组件拒绝请求的原因
+type RejectReason struct {
+	// 原因码，由组件自行定义，便于调用方区分处理
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
 }
 
 // tcc 组件
@@ -0,0 +1,167 @@
+package coordinator
+
+import (
+	"time"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+)
+
+// 事务协调器对外暴露的 json over http 协议:
+//  1. 注册参与方: POST /participants，请求体为 RegisterParticipantReq；
+//     PUT 请求替换已注册参与方的地址，DELETE /participants?componentID={componentID} 注销参与方
+//  2. 开启事务: POST /transactions/begin，响应体为 BeginResp
+//  3. 登记分支: POST /transactions/branch，请求体为 RegisterBranchReq
+//  4. 提交事务: POST /transactions/commit，请求体为 CommitReq，响应体为 CommitResp
+//  5. 查询事务: GET /transactions/query?txID={txID}，响应体为 QueryResp
+//  6. 回调异步 try 的结果: POST /transactions/report，请求体为 ReportTryReq. 参与方的 try 响应 pending 时，
+//     在得出结果后通过该接口回调；事务或分支不存在时返回 404，与已记录的结果冲突时返回 409
+//  7. 审批事务: POST /transactions/approve，请求体为 ApproveReq. 供管理后台审批处于 awaiting-approval 状态的事务；
+//     事务不存在时返回 404，事务不处于待审批状态或者与已有审批结果冲突时返回 409
+//  8. 错误响应: 响应体为 ErrorResp
+//
+// begin 与 branch 阶段登记的分支仅暂存在协调器内存中，commit 时才会创建事务日志并执行两阶段提交
+const (
+	PathParticipants   = "/participants"
+	PathBegin          = "/transactions/begin"
+	PathRegisterBranch = "/transactions/branch"
+	PathCommit         = "/transactions/commit"
+	PathQuery          = "/transactions/query"
+	PathReportTry      = "/transactions/report"
+	PathApprove        = "/transactions/approve"
+)
+
+// 注册参与方请求参数，协调器通过 httptransport 协议访问参与方
+type RegisterParticipantReq struct {
+	ComponentID string `json:"componentID"`
+	URL         string `json:"url"`
+}
+
+// 开启事务响应结果
+type BeginResp struct {
+	// 暂存分支的草稿 id，在 commit 之前有效
+	DraftID string `json:"draftID"`
+	// 草稿过期时间，过期后未提交的分支会被丢弃
+	ExpireAt time.Time `json:"expireAt"`
+}
+
+// 登记分支请求参数
+type RegisterBranchReq struct {
+	DraftID     string                 `json:"draftID"`
+	ComponentID string                 `json:"componentID"`
+	Request     map[string]interface{} `json:"request"`
+	// 执行阶段，可选. 阶段小的分支先执行 try
+	Stage int `json:"stage,omitempty"`
+	// 依赖的其他分支的组件 id，可选. 依赖的分支全部 try 成功后才会执行
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// 提交事务请求参数
+type CommitReq struct {
+	DraftID string `json:"draftID"`
+	// 幂等键，可选. 相同幂等键的事务只会执行一次，携带幂等键时草稿在过期前可以重复提交
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	// 审批时长，单位为毫秒，可选. 大于 0 时事务 try 全部成功后等待审批
+	ApprovalTimeoutMS int64 `json:"approvalTimeoutMs,omitempty"`
+	// 定时 confirm 的时间，可选. try 全部成功后等到该时间才会 confirm
+	ConfirmAt time.Time `json:"confirmAt,omitempty"`
+}
+
+// 提交事务响应结果
+type CommitResp struct {
+	TXID    string `json:"txID"`
+	Success bool   `json:"success"`
+	// 事务的状态. 第二阶段交由轮询任务处理时为 hanging
+	Status gotcc.TXStatus `json:"status"`
+	// try 阶段失败的原因
+	Message string `json:"message,omitempty"`
+	// 各分支 try 请求的执行结果
+	Components []*ComponentResp `json:"components"`
+}
+
+// 回调异步 try 结果的请求参数
+type ReportTryReq struct {
+	TXID        string `json:"txID"`
+	ComponentID string `json:"componentID"`
+	ACK         bool   `json:"ack"`
+}
+
+// 审批事务请求参数
+type ApproveReq struct {
+	TXID string `json:"txID"`
+	// 为 true 时审批通过并 confirm，否则驳回并 cancel
+	Approve bool `json:"approve"`
+}
+
+// 查询事务响应结果
+type QueryResp struct {
+	TXID       string           `json:"txID"`
+	Status     gotcc.TXStatus   `json:"status"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	Components []*ComponentResp `json:"components"`
+}
+
+type ComponentResp struct {
+	ComponentID string                   `json:"componentID"`
+	TryStatus   gotcc.ComponentTryStatus `json:"tryStatus"`
+	// try 响应了 pending，正在等待参与方通过 /transactions/report 回调结果
+	Pending bool `json:"pending,omitempty"`
+	// try 的执行结果
+	Result map[string]interface{} `json:"result,omitempty"`
+	// 拒绝原因
+	Reason *gotcc.RejectReason `json:"reason,omitempty"`
+	// try 请求报错或者被拒绝时的错误信息
+	Message string `json:"message,omitempty"`
+}
+
+// 错误响应
+type ErrorResp struct {
+	Message string `json:"message"`
+}
+
+func toQueryResp(tx *gotcc.Transaction) *QueryResp {
+	resp := QueryResp{
+		TXID:       tx.TXID,
+		Status:     tx.Status,
+		CreatedAt:  tx.CreatedAt,
+		Components: make([]*ComponentResp, 0, len(tx.Components)),
+	}
+	for _, component := range tx.Components {
+		componentResp := ComponentResp{
+			ComponentID: component.ComponentID,
+			TryStatus:   component.TryStatus,
+			Reason:      component.Reason,
+		}
+		// 执行结果的载荷无法解码时忽略
+		if component.Result != nil {
+			_ = component.Result.Decode(&componentResp.Result)
+		}
+		resp.Components = append(resp.Components, &componentResp)
+	}
+	return &resp
+}
+
+func toCommitResp(result *gotcc.TXResult) *CommitResp {
+	resp := CommitResp{
+		TXID:       result.TXID,
+		Success:    result.Success,
+		Status:     result.Status,
+		Components: make([]*ComponentResp, 0, len(result.Components)),
+	}
+	if result.Err != nil {
+		resp.Message = result.Err.Error()
+	}
+	for _, component := range result.Components {
+		componentResp := ComponentResp{
+			ComponentID: component.ComponentID,
+			TryStatus:   component.TryStatus,
+			Pending:     component.Pending,
+			Result:      component.Result,
+			Reason:      component.Reason,
+		}
+		if component.Err != nil {
+			componentResp.Message = component.Err.Error()
+		}
+		resp.Components = append(resp.Components, &componentResp)
+	}
+	return &resp
+}
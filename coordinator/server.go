@@ -0,0 +1,351 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/httptransport"
+)
+
+type Options struct {
+	// 草稿的有效时长，超过该时长仍未提交的草稿会被丢弃
+	DraftTTL time.Duration
+	// 访问参与方时使用的 http client 参数
+	ClientOptions []httptransport.ClientOption
+}
+
+type Option func(*Options)
+
+func WithDraftTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.DraftTTL = ttl
+	}
+}
+
+func WithClientOptions(opts ...httptransport.ClientOption) Option {
+	return func(o *Options) {
+		o.ClientOptions = append(o.ClientOptions, opts...)
+	}
+}
+
+func repair(o *Options) {
+	if o.DraftTTL <= 0 {
+		o.DraftTTL = time.Minute
+	}
+}
+
+// 独立部署的事务协调器，基于 TXManager 对外提供 json over http 的事务接口.
+// 调用方无需引入 gotcc，也无需直接访问事务日志存储
+type Server struct {
+	manager *gotcc.TXManager
+	opts    *Options
+	mux     *http.ServeMux
+
+	draftMux sync.Mutex
+	drafts   map[string]*draft
+}
+
+// 暂存的事务分支
+type draft struct {
+	mux      sync.Mutex
+	reqs     []*gotcc.RequestEntity
+	expireAt time.Time
+	// 已经提交过，不再接受新的分支
+	committed bool
+}
+
+func NewServer(manager *gotcc.TXManager, opts ...Option) *Server {
+	s := Server{
+		manager: manager,
+		opts:    &Options{},
+		mux:     http.NewServeMux(),
+		drafts:  make(map[string]*draft),
+	}
+	for _, opt := range opts {
+		opt(s.opts)
+	}
+	repair(s.opts)
+
+	s.mux.HandleFunc(PathParticipants, s.participants)
+	s.mux.HandleFunc(PathBegin, post(s.begin))
+	s.mux.HandleFunc(PathRegisterBranch, post(s.registerBranch))
+	s.mux.HandleFunc(PathCommit, post(s.commit))
+	s.mux.HandleFunc(PathQuery, s.query)
+	s.mux.HandleFunc(PathReportTry, post(s.reportTry))
+	s.mux.HandleFunc(PathApprove, post(s.approve))
+	return &s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) participants(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		s.registerParticipant(w, r)
+	case http.MethodDelete:
+		s.unregisterParticipant(w, r)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, &ErrorResp{Message: "method not allowed"})
+	}
+}
+
+func (s *Server) registerParticipant(w http.ResponseWriter, r *http.Request) {
+	var req RegisterParticipantReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: err.Error()})
+		return
+	}
+	if req.ComponentID == "" || req.URL == "" {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: "empty componentID or url"})
+		return
+	}
+
+	// PUT 请求用于替换已注册参与方的地址
+	client := httptransport.NewClient(req.ComponentID, req.URL, s.opts.ClientOptions...)
+	register := s.manager.Register
+	if r.Method == http.MethodPut {
+		register = s.manager.Replace
+	}
+	if err := register(client); err != nil {
+		writeJSON(w, http.StatusConflict, &ErrorResp{Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *Server) unregisterParticipant(w http.ResponseWriter, r *http.Request) {
+	componentID := r.URL.Query().Get("componentID")
+	if componentID == "" {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: "empty componentID"})
+		return
+	}
+	if err := s.manager.Unregister(r.Context(), componentID); err != nil {
+		writeJSON(w, http.StatusConflict, &ErrorResp{Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *Server) begin(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	d := draft{expireAt: now.Add(s.opts.DraftTTL)}
+	draftID := uuid.NewString()
+
+	s.draftMux.Lock()
+	// 顺带清理过期的草稿
+	for id, d := range s.drafts {
+		if d.expireAt.Before(now) {
+			delete(s.drafts, id)
+		}
+	}
+	s.drafts[draftID] = &d
+	s.draftMux.Unlock()
+
+	writeJSON(w, http.StatusOK, &BeginResp{DraftID: draftID, ExpireAt: d.expireAt})
+}
+
+func (s *Server) registerBranch(w http.ResponseWriter, r *http.Request) {
+	var req RegisterBranchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: err.Error()})
+		return
+	}
+	if req.ComponentID == "" {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: "empty componentID"})
+		return
+	}
+
+	d, err := s.getDraft(req.DraftID, false)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, &ErrorResp{Message: err.Error()})
+		return
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if d.committed {
+		writeJSON(w, http.StatusConflict, &ErrorResp{Message: fmt.Sprintf("draft already committed: %s", req.DraftID)})
+		return
+	}
+	for _, branch := range d.reqs {
+		if branch.ComponentID == req.ComponentID {
+			writeJSON(w, http.StatusConflict, &ErrorResp{Message: fmt.Sprintf("repeat component: %s", req.ComponentID)})
+			return
+		}
+	}
+	d.reqs = append(d.reqs, &gotcc.RequestEntity{
+		ComponentID: req.ComponentID,
+		Request:     req.Request,
+		Stage:       req.Stage,
+		DependsOn:   req.DependsOn,
+	})
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *Server) commit(w http.ResponseWriter, r *http.Request) {
+	var req CommitReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: err.Error()})
+		return
+	}
+
+	d, err := s.getDraft(req.DraftID, false)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, &ErrorResp{Message: err.Error()})
+		return
+	}
+
+	// 未携带幂等键的草稿只能提交一次. 携带幂等键时允许重复提交，由 TXManager 基于幂等键返回已有事务的结果
+	d.mux.Lock()
+	if d.committed && req.IdempotencyKey == "" {
+		d.mux.Unlock()
+		writeJSON(w, http.StatusNotFound, &ErrorResp{Message: fmt.Sprintf("draft already committed: %s", req.DraftID)})
+		return
+	}
+	d.committed = true
+	reqs := d.reqs
+	d.mux.Unlock()
+
+	var txOpts []gotcc.TXOption
+	if req.IdempotencyKey != "" {
+		txOpts = append(txOpts, gotcc.WithIdempotencyKey(req.IdempotencyKey))
+	}
+	if req.ApprovalTimeoutMS > 0 {
+		txOpts = append(txOpts, gotcc.WithApproval(time.Duration(req.ApprovalTimeoutMS)*time.Millisecond))
+	}
+	if !req.ConfirmAt.IsZero() {
+		txOpts = append(txOpts, gotcc.WithConfirmAt(req.ConfirmAt))
+	}
+	result, err := s.manager.Transaction(r.Context(), reqs, txOpts...)
+	if err != nil {
+		writeJSON(w, commitErrStatus(err), &ErrorResp{Message: err.Error()})
+		return
+	}
+	// 携带幂等键的草稿保留至过期，供调用方重试提交
+	if req.IdempotencyKey == "" {
+		_, _ = s.getDraft(req.DraftID, true)
+	}
+	writeJSON(w, http.StatusOK, toCommitResp(result))
+}
+
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, &ErrorResp{Message: "method not allowed"})
+		return
+	}
+	txID := r.URL.Query().Get("txID")
+	if txID == "" {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: "empty txID"})
+		return
+	}
+
+	tx, err := s.manager.GetTX(r.Context(), txID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, &ErrorResp{Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, toQueryResp(tx))
+}
+
+func (s *Server) reportTry(w http.ResponseWriter, r *http.Request) {
+	var req ReportTryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: err.Error()})
+		return
+	}
+	if req.TXID == "" || req.ComponentID == "" {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: "empty txID or componentID"})
+		return
+	}
+
+	if err := s.manager.ReportTry(r.Context(), req.TXID, req.ComponentID, req.ACK); err != nil {
+		writeJSON(w, resolveErrStatus(err), &ErrorResp{Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *Server) approve(w http.ResponseWriter, r *http.Request) {
+	var req ApproveReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: err.Error()})
+		return
+	}
+	if req.TXID == "" {
+		writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: "empty txID"})
+		return
+	}
+
+	if err := s.manager.ApproveTX(r.Context(), req.TXID, req.Approve); err != nil {
+		writeJSON(w, resolveErrStatus(err), &ErrorResp{Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (s *Server) getDraft(draftID string, remove bool) (*draft, error) {
+	s.draftMux.Lock()
+	defer s.draftMux.Unlock()
+	d, ok := s.drafts[draftID]
+	if !ok || d.expireAt.Before(time.Now()) {
+		delete(s.drafts, draftID)
+		return nil, errors.New("draft not existed or expired")
+	}
+	if remove {
+		delete(s.drafts, draftID)
+	}
+	return d, nil
+}
+
+// 事务的请求参数非法时返回 400，其余错误返回 500
+func commitErrStatus(err error) int {
+	for _, target := range []error{
+		gotcc.ErrEmptyTransaction,
+		gotcc.ErrComponentNotFound,
+		gotcc.ErrDuplicateComponent,
+		gotcc.ErrInvalidRequest,
+		gotcc.ErrInvalidDependency,
+	} {
+		if errors.Is(err, target) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// 回调或审批的事务、分支不存在时返回 404，与已记录的状态冲突时返回 409，其余错误返回 500
+func resolveErrStatus(err error) int {
+	switch {
+	case errors.Is(err, gotcc.ErrTXNotFound), errors.Is(err, gotcc.ErrComponentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, gotcc.ErrStoreConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func post(handle http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, &ErrorResp{Message: "method not allowed"})
+			return
+		}
+		handle(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
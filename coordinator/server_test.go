@@ -0,0 +1,309 @@
+package coordinator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/httptransport"
+)
+
+type mockTXStore struct {
+	mutex sync.Mutex
+	txs   map[string]*gotcc.Transaction
+}
+
+func (m *mockTXStore) CreateTX(ctx context.Context, components ...gotcc.TCCComponent) (string, error) {
+	tx := gotcc.Transaction{
+		TXID:      uuid.NewString(),
+		Status:    gotcc.TXHanging,
+		CreatedAt: time.Now(),
+	}
+	for _, component := range components {
+		tx.Components = append(tx.Components, &gotcc.ComponentTryEntity{
+			ComponentID: component.ID(),
+			TryStatus:   gotcc.TryHanging,
+		})
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.txs[tx.TXID] = &tx
+	return tx.TXID, nil
+}
+
+func (m *mockTXStore) TXUpdate(ctx context.Context, txID string, componentID string, accept bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, component := range m.txs[txID].Components {
+		if component.ComponentID != componentID {
+			continue
+		}
+		component.TryStatus = gotcc.TryFailure
+		if accept {
+			component.TryStatus = gotcc.TrySucceesful
+		}
+	}
+	return nil
+}
+
+func (m *mockTXStore) TXSubmit(ctx context.Context, txID string, success bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.txs[txID].Status = gotcc.TXFailure
+	if success {
+		m.txs[txID].Status = gotcc.TXSuccessful
+	}
+	return nil
+}
+
+func (m *mockTXStore) GetHangingTXs(ctx context.Context) ([]*gotcc.Transaction, error) {
+	return nil, nil
+}
+
+func (m *mockTXStore) GetTX(ctx context.Context, txID string) (*gotcc.Transaction, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	tx, ok := m.txs[txID]
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid txid: %s", gotcc.ErrTXNotFound, txID)
+	}
+	// 拷贝组件的 try 记录，避免调用方与 TXUpdate 并发读写同一份数据
+	copied := *tx
+	copied.Components = make([]*gotcc.ComponentTryEntity, 0, len(tx.Components))
+	for _, component := range tx.Components {
+		c := *component
+		copied.Components = append(copied.Components, &c)
+	}
+	return &copied, nil
+}
+
+func (m *mockTXStore) Lock(ctx context.Context, expireDuration time.Duration) error {
+	return nil
+}
+
+func (m *mockTXStore) Unlock(ctx context.Context) error {
+	return nil
+}
+
+// 支持幂等键的事务日志存储
+type mockIdempotentTXStore struct {
+	*mockTXStore
+}
+
+func (m *mockIdempotentTXStore) CreateTXDetail(ctx context.Context, tx *gotcc.Transaction) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, existed := range m.txs {
+		if tx.IdempotencyKey != "" && existed.IdempotencyKey == tx.IdempotencyKey {
+			return "", gotcc.ErrDuplicateIdempotencyKey
+		}
+	}
+	copied := *tx
+	copied.TXID = uuid.NewString()
+	m.txs[copied.TXID] = &copied
+	return copied.TXID, nil
+}
+
+func (m *mockIdempotentTXStore) GetTXByIdempotencyKey(ctx context.Context, key string) (*gotcc.Transaction, error) {
+	m.mutex.Lock()
+	var txID string
+	for _, tx := range m.txs {
+		if tx.IdempotencyKey == key {
+			txID = tx.TXID
+		}
+	}
+	m.mutex.Unlock()
+	if txID == "" {
+		return nil, nil
+	}
+	return m.GetTX(ctx, txID)
+}
+
+type mockComponent struct {
+	id string
+}
+
+func (m *mockComponent) ID() string {
+	return m.id
+}
+
+func (m *mockComponent) Try(ctx context.Context, req *gotcc.TCCReq) (*gotcc.TCCResp, error) {
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: req.TXID, ACK: req.Data["reject"] != true}, nil
+}
+
+func (m *mockComponent) Confirm(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: txID, ACK: true}, nil
+}
+
+func (m *mockComponent) Cancel(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: txID, ACK: true}, nil
+}
+
+func do(t *testing.T, url string, req, resp interface{}) int {
+	var httpResp *http.Response
+	var err error
+	if req == nil {
+		httpResp, err = http.Get(url)
+	} else {
+		body, _ := json.Marshal(req)
+		httpResp, err = http.Post(url, "application/json", bytes.NewReader(body))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+	if resp != nil {
+		_ = json.NewDecoder(httpResp.Body).Decode(resp)
+	}
+	return httpResp.StatusCode
+}
+
+func Test_Server(t *testing.T) {
+	manager := gotcc.NewTXManager(&mockTXStore{txs: make(map[string]*gotcc.Transaction)})
+	defer manager.Stop()
+	server := httptest.NewServer(NewServer(manager))
+	defer server.Close()
+
+	// 以 url 的形式注册远端参与方
+	for _, id := range []string{"a", "b"} {
+		participant := httptest.NewServer(httptransport.NewHandler(&mockComponent{id: id}))
+		defer participant.Close()
+		assert.Equal(t, http.StatusOK, do(t, server.URL+PathParticipants, &RegisterParticipantReq{ComponentID: id, URL: participant.URL}, nil))
+	}
+	assert.Equal(t, http.StatusConflict, do(t, server.URL+PathParticipants, &RegisterParticipantReq{ComponentID: "a", URL: "http://127.0.0.1"}, nil))
+	version, err := manager.ComponentVersion("a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(0), version)
+
+	// 没有登记任何分支的事务属于非法请求
+	var empty BeginResp
+	assert.Equal(t, http.StatusOK, do(t, server.URL+PathBegin, struct{}{}, &empty))
+	assert.Equal(t, http.StatusBadRequest, do(t, server.URL+PathCommit, &CommitReq{DraftID: empty.DraftID}, nil))
+
+	tests := []struct {
+		name    string
+		reject  bool
+		success bool
+		status  gotcc.TXStatus
+	}{
+		{name: "success", success: true, status: gotcc.TXSuccessful},
+		{name: "reject", reject: true, status: gotcc.TXFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var begin BeginResp
+			assert.Equal(t, http.StatusOK, do(t, server.URL+PathBegin, struct{}{}, &begin))
+			assert.NotEmpty(t, begin.DraftID)
+
+			assert.Equal(t, http.StatusOK, do(t, server.URL+PathRegisterBranch, &RegisterBranchReq{DraftID: begin.DraftID, ComponentID: "a"}, nil))
+			assert.Equal(t, http.StatusOK, do(t, server.URL+PathRegisterBranch, &RegisterBranchReq{
+				DraftID:     begin.DraftID,
+				ComponentID: "b",
+				Request:     map[string]interface{}{"reject": tt.reject},
+			}, nil))
+			assert.Equal(t, http.StatusConflict, do(t, server.URL+PathRegisterBranch, &RegisterBranchReq{DraftID: begin.DraftID, ComponentID: "b"}, nil))
+
+			var commit CommitResp
+			assert.Equal(t, http.StatusOK, do(t, server.URL+PathCommit, &CommitReq{DraftID: begin.DraftID}, &commit))
+			assert.Equal(t, tt.success, commit.Success)
+			assert.Equal(t, 2, len(commit.Components))
+			assert.Equal(t, tt.status, commit.Status)
+			assert.Equal(t, tt.reject, commit.Message != "")
+			// 草稿只能提交一次
+			assert.Equal(t, http.StatusNotFound, do(t, server.URL+PathCommit, &CommitReq{DraftID: begin.DraftID}, nil))
+
+			var query QueryResp
+			assert.Equal(t, http.StatusOK, do(t, server.URL+PathQuery+"?txID="+commit.TXID, nil, &query))
+			assert.Equal(t, commit.TXID, query.TXID)
+			assert.Equal(t, tt.status, query.Status)
+			assert.Equal(t, 2, len(query.Components))
+
+			// 事务结束后回调与已记录结果相反的 try 结果. a 与 b 并发 try，b 拒绝时 a 的结果取决于执行时序，因此以 b 为准
+			assert.Equal(t, http.StatusConflict, do(t, server.URL+PathReportTry, &ReportTryReq{TXID: commit.TXID, ComponentID: "b", ACK: tt.reject}, nil))
+			assert.Equal(t, http.StatusOK, do(t, server.URL+PathReportTry, &ReportTryReq{TXID: commit.TXID, ComponentID: "b", ACK: !tt.reject}, nil))
+			// 不需要审批的事务无法审批
+			assert.Equal(t, http.StatusConflict, do(t, server.URL+PathApprove, &ApproveReq{TXID: commit.TXID, Approve: true}, nil))
+		})
+	}
+}
+
+func Test_Server_idempotent_commit(t *testing.T) {
+	manager := gotcc.NewTXManager(&mockIdempotentTXStore{mockTXStore: &mockTXStore{txs: make(map[string]*gotcc.Transaction)}})
+	defer manager.Stop()
+	assert.Equal(t, nil, manager.Register(&mockComponent{id: "a"}))
+	server := httptest.NewServer(NewServer(manager))
+	defer server.Close()
+
+	var begin BeginResp
+	assert.Equal(t, http.StatusOK, do(t, server.URL+PathBegin, struct{}{}, &begin))
+	assert.Equal(t, http.StatusOK, do(t, server.URL+PathRegisterBranch, &RegisterBranchReq{DraftID: begin.DraftID, ComponentID: "a"}, nil))
+
+	var commit CommitResp
+	assert.Equal(t, http.StatusOK, do(t, server.URL+PathCommit, &CommitReq{DraftID: begin.DraftID, IdempotencyKey: "key"}, &commit))
+	assert.True(t, commit.Success)
+	// 已提交的草稿不再接受新的分支
+	assert.Equal(t, http.StatusConflict, do(t, server.URL+PathRegisterBranch, &RegisterBranchReq{DraftID: begin.DraftID, ComponentID: "b"}, nil))
+
+	// 携带相同的幂等键重试提交，返回已有的事务
+	var retry CommitResp
+	assert.Equal(t, http.StatusOK, do(t, server.URL+PathCommit, &CommitReq{DraftID: begin.DraftID, IdempotencyKey: "key"}, &retry))
+	assert.Equal(t, commit.TXID, retry.TXID)
+	assert.True(t, retry.Success)
+	// 不携带幂等键时草稿只能提交一次
+	assert.Equal(t, http.StatusNotFound, do(t, server.URL+PathCommit, &CommitReq{DraftID: begin.DraftID}, nil))
+}
+
+func Test_Server_draft_expired(t *testing.T) {
+	manager := gotcc.NewTXManager(&mockTXStore{txs: make(map[string]*gotcc.Transaction)})
+	defer manager.Stop()
+	server := httptest.NewServer(NewServer(manager, WithDraftTTL(time.Millisecond)))
+	defer server.Close()
+
+	var begin BeginResp
+	assert.Equal(t, http.StatusOK, do(t, server.URL+PathBegin, struct{}{}, &begin))
+	<-time.After(5 * time.Millisecond)
+	assert.Equal(t, http.StatusNotFound, do(t, server.URL+PathRegisterBranch, &RegisterBranchReq{DraftID: begin.DraftID, ComponentID: "a"}, nil))
+	assert.Equal(t, http.StatusNotFound, do(t, server.URL+PathQuery+"?txID=unknown", nil, nil))
+	assert.Equal(t, http.StatusNotFound, do(t, server.URL+PathReportTry, &ReportTryReq{TXID: "unknown", ComponentID: "a"}, nil))
+	assert.Equal(t, http.StatusBadRequest, do(t, server.URL+PathReportTry, &ReportTryReq{}, nil))
+	assert.Equal(t, http.StatusNotFound, do(t, server.URL+PathApprove, &ApproveReq{TXID: "unknown"}, nil))
+	assert.Equal(t, http.StatusBadRequest, do(t, server.URL+PathApprove, &ApproveReq{}, nil))
+}
+
+func Test_Server_participants(t *testing.T) {
+	manager := gotcc.NewTXManager(&mockTXStore{txs: make(map[string]*gotcc.Transaction)})
+	defer manager.Stop()
+	server := httptest.NewServer(NewServer(manager))
+	defer server.Close()
+
+	send := func(method, url string, req interface{}) int {
+		body, _ := json.Marshal(req)
+		httpReq, _ := http.NewRequest(method, url, bytes.NewReader(body))
+		httpResp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer httpResp.Body.Close()
+		return httpResp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusConflict, send(http.MethodPut, server.URL+PathParticipants, &RegisterParticipantReq{ComponentID: "a", URL: "http://127.0.0.1:1"}))
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, server.URL+PathParticipants, &RegisterParticipantReq{ComponentID: "a", URL: "http://127.0.0.1:1"}))
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, server.URL+PathParticipants, &RegisterParticipantReq{ComponentID: "a", URL: "http://127.0.0.1:2"}))
+	version, err := manager.ComponentVersion("a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(1), version)
+
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, server.URL+PathParticipants+"?componentID=a", nil))
+	assert.Equal(t, http.StatusConflict, send(http.MethodDelete, server.URL+PathParticipants+"?componentID=a", nil))
+}
@@ -0,0 +1,99 @@
+package gotcc
+
+import (
+	"fmt"
+)
+
+// 事务分支，即参与事务的一个组件
+type branch struct {
+	id        string
+	stage     int
+	dependsOn []string
+	// 是否为 saga 分支
+	saga bool
+}
+
+// 事务分支之间的依赖关系. 显式声明的依赖之外，每个分支还隐式依赖于所有阶段更小的分支，
+// 每个 saga 分支还隐式依赖于排在它之前的上一个 saga 分支
+type branchGraph struct {
+	ids  []string
+	deps map[string][]string
+}
+
+func newBranchGraph(branches []branch) (*branchGraph, error) {
+	g := branchGraph{
+		ids:  make([]string, 0, len(branches)),
+		deps: make(map[string][]string, len(branches)),
+	}
+	existed := make(map[string]struct{}, len(branches))
+	for _, b := range branches {
+		existed[b.id] = struct{}{}
+		g.ids = append(g.ids, b.id)
+	}
+
+	var prevSaga string
+	for _, b := range branches {
+		deps := make([]string, 0, len(b.dependsOn))
+		seen := make(map[string]struct{})
+		for _, dep := range b.dependsOn {
+			if _, ok := existed[dep]; !ok {
+				return nil, componentError(b.id, "", fmt.Errorf("%w: depends on unknown component: %s", ErrInvalidDependency, dep))
+			}
+			if _, ok := seen[dep]; !ok {
+				seen[dep] = struct{}{}
+				deps = append(deps, dep)
+			}
+		}
+		for _, other := range branches {
+			if _, ok := seen[other.id]; !ok && other.stage < b.stage {
+				seen[other.id] = struct{}{}
+				deps = append(deps, other.id)
+			}
+		}
+		if b.saga {
+			if _, ok := seen[prevSaga]; !ok && prevSaga != "" {
+				deps = append(deps, prevSaga)
+			}
+			prevSaga = b.id
+		}
+		g.deps[b.id] = deps
+	}
+
+	if _, err := g.order(); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// 拓扑序，依赖关系之外保持分支原有的先后顺序. 存在环时报错
+func (g *branchGraph) order() ([]string, error) {
+	indegree := make(map[string]int, len(g.ids))
+	dependents := make(map[string][]string, len(g.ids))
+	for _, id := range g.ids {
+		indegree[id] = len(g.deps[id])
+		for _, dep := range g.deps[id] {
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	ordered := make([]string, 0, len(g.ids))
+	visited := make(map[string]bool, len(g.ids))
+	for len(ordered) < len(g.ids) {
+		progressed := false
+		for _, id := range g.ids {
+			if visited[id] || indegree[id] > 0 {
+				continue
+			}
+			visited[id] = true
+			progressed = true
+			ordered = append(ordered, id)
+			for _, dependent := range dependents[id] {
+				indegree[dependent]--
+			}
+		}
+		if !progressed {
+			return nil, fmt.Errorf("%w: cyclic dependencies between components", ErrInvalidDependency)
+		}
+	}
+	return ordered, nil
+}
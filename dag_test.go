@@ -0,0 +1,224 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// try 成功时返回携带预留单号的执行结果
+type resultComponent struct {
+	TCCComponent
+	recorder *recorder
+	mutex    sync.Mutex
+	request  map[string]interface{}
+}
+
+func (r *resultComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	r.recorder.record(r.ID(), PhaseTry)
+	r.mutex.Lock()
+	r.request = req.Data
+	r.mutex.Unlock()
+	resp, err := r.TCCComponent.Try(ctx, req)
+	if err != nil || !resp.ACK {
+		return resp, err
+	}
+	resp.Result = map[string]interface{}{"reservation": r.ID() + "_" + req.TXID}
+	return resp, nil
+}
+
+func (r *resultComponent) Confirm(ctx context.Context, txID string) (*TCCResp, error) {
+	r.recorder.record(r.ID(), PhaseConfirm)
+	return r.TCCComponent.Confirm(ctx, txID)
+}
+
+func (r *resultComponent) Cancel(ctx context.Context, txID string) (*TCCResp, error) {
+	r.recorder.record(r.ID(), PhaseCancel)
+	return r.TCCComponent.Cancel(ctx, txID)
+}
+
+func Test_newBranchGraph(t *testing.T) {
+	tests := []struct {
+		name      string
+		branches  []branch
+		expectErr bool
+		order     []string
+	}{
+		{
+			name: "depends on",
+			branches: []branch{
+				{id: "charge", dependsOn: []string{"inventory"}},
+				{id: "inventory"},
+				{id: "notify", dependsOn: []string{"charge", "charge"}},
+			},
+			order: []string{"inventory", "charge", "notify"},
+		},
+		{
+			name: "stage",
+			branches: []branch{
+				{id: "charge", stage: 1},
+				{id: "inventory"},
+				{id: "coupon"},
+			},
+			order: []string{"inventory", "coupon", "charge"},
+		},
+		{
+			name:      "unknown",
+			branches:  []branch{{id: "charge", dependsOn: []string{"inventory"}}},
+			expectErr: true,
+		},
+		{
+			name: "cycle",
+			branches: []branch{
+				{id: "charge", dependsOn: []string{"inventory"}},
+				{id: "inventory", dependsOn: []string{"charge"}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newBranchGraph(tt.branches)
+			assert.Equal(t, tt.expectErr, err != nil)
+			if err != nil {
+				return
+			}
+			order, err := g.order()
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.order, order)
+		})
+	}
+}
+
+func Test_txManager_dag(t *testing.T) {
+	tests := []struct {
+		name    string
+		reject  bool
+		success bool
+		calls   []string
+	}{
+		{
+			name:    "success",
+			success: true,
+			calls:   []string{"inventory:try", "charge:try", "inventory:confirm", "charge:confirm"},
+		},
+		{
+			// 依赖的组件失败时不再执行 try，cancel 按照依赖关系逆序执行
+			name:   "reject",
+			reject: true,
+			calls:  []string{"inventory:try", "charge:cancel", "inventory:cancel"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txManager := NewTXManager(newMockTXStore())
+			defer txManager.Stop()
+			var r recorder
+			charge := resultComponent{TCCComponent: newMockComponent("charge"), recorder: &r}
+			assert.Equal(t, nil, txManager.Register(&resultComponent{TCCComponent: newMockComponent("inventory"), recorder: &r}))
+			assert.Equal(t, nil, txManager.Register(&charge))
+
+			result, err := txManager.Transaction(context.Background(), []*RequestEntity{
+				{
+					ComponentID: "charge",
+					Request:     map[string]interface{}{"amount": 1},
+					DependsOn:   []string{"inventory"},
+					// 基于库存预留结果构造扣款请求
+					BuildRequest: func(request map[string]interface{}, deps map[string]*TCCResp) (map[string]interface{}, error) {
+						built := map[string]interface{}{"reservation": deps["inventory"].Result["reservation"]}
+						for k, v := range request {
+							built[k] = v
+						}
+						return built, nil
+					},
+				},
+				{ComponentID: "inventory", Request: map[string]interface{}{"reject_flag": tt.reject}},
+			})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.calls, r.calls)
+			if !tt.success {
+				return
+			}
+
+			charge.mutex.Lock()
+			assert.Equal(t, map[string]interface{}{"amount": 1, "reservation": "inventory_" + result.TXID}, charge.request)
+			charge.mutex.Unlock()
+
+			// 依赖关系随事务日志持久化
+			tx, err := txManager.GetTX(context.Background(), result.TXID)
+			assert.Equal(t, nil, err)
+			for _, component := range tx.Components {
+				if component.ComponentID == "charge" {
+					assert.Equal(t, []string{"inventory"}, component.DependsOn)
+				}
+			}
+		})
+	}
+}
+
+func Test_txManager_dag_invalid(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore())
+	defer txManager.Stop()
+	assert.Equal(t, nil, txManager.Register(newMockComponent("inventory")))
+	assert.Equal(t, nil, txManager.Register(newMockComponent("charge")))
+
+	_, err := txManager.Transaction(context.Background(), []*RequestEntity{
+		{ComponentID: "charge", DependsOn: []string{"coupon"}},
+		{ComponentID: "inventory"},
+	})
+	assert.NotEqual(t, nil, err)
+
+	_, err = txManager.Transaction(context.Background(), []*RequestEntity{
+		{ComponentID: "charge", DependsOn: []string{"inventory"}},
+		{ComponentID: "inventory", DependsOn: []string{"charge"}},
+	})
+	assert.NotEqual(t, nil, err)
+}
+
+// try 时忽略 ctx，直到 release 关闭才返回
+type blockingComponent struct {
+	TCCComponent
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	close(b.entered)
+	<-b.release
+	return b.TCCComponent.Try(ctx, req)
+}
+
+func Test_txManager_dag_canceled(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var r recorder
+	a := blockingComponent{TCCComponent: newMockComponent("a"), entered: make(chan struct{}), release: make(chan struct{})}
+	defer close(a.release)
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: &a, recorder: &r}))
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("b"), recorder: &r}))
+
+	// 调用方在 a 执行 try 期间取消，依赖于 a 的 b 不再执行 try，事务不能视为成功
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-a.entered
+		cancel()
+	}()
+	result, err := txManager.Transaction(ctx, []*RequestEntity{
+		{ComponentID: "a"},
+		{ComponentID: "b", DependsOn: []string{"a"}},
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, result.Success)
+	assert.Equal(t, true, errors.Is(result.Err, context.Canceled))
+	assert.Equal(t, TryFailure, result.Component("b").TryStatus)
+	// b 的失败已记录到事务日志，事务随即 cancel
+	assert.Equal(t, TXFailure, result.Status)
+	assert.Equal(t, []string{"a:try", "b:cancel", "a:cancel"}, r.calls)
+}
@@ -0,0 +1,71 @@
+package gotcc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 包内返回的错误均会包装以下哨兵错误，调用方可以通过 errors.Is 进行判断
+var (
+	// 事务中没有任何组件
+	ErrEmptyTransaction = errors.New("empty transaction")
+	// 组件未注册，或者正在注销中
+	ErrComponentNotFound = errors.New("component not found")
+	// 组件 id 重复，包括重复注册以及同一笔事务中重复使用同一个组件
+	ErrDuplicateComponent = errors.New("duplicate component")
+	// 组件正在注销中
+	ErrComponentUnregistering = errors.New("component is unregistering")
+	// 组件仍然存在未完成的事务，无法注销
+	ErrComponentBusy = errors.New("component has hanging tx")
+	// 组件的版本号不高于当前版本
+	ErrStaleVersion = errors.New("stale component version")
+	// 组件的请求参数非法
+	ErrInvalidRequest = errors.New("invalid request")
+	// 组件之间的依赖关系非法，例如依赖了未参与事务的组件或者存在环
+	ErrInvalidDependency = errors.New("invalid dependency")
+	// 组件拒绝了 try 请求
+	ErrTryRejected = errors.New("try rejected")
+	// try 请求报错
+	ErrTryFailed = errors.New("try failed")
+	// 第二阶段的 confirm/cancel 请求未被组件确认
+	ErrNotAcked = errors.New("not acked")
+	// 事务不存在. TXStore.GetTX 的实现需要包装该错误
+	ErrTXNotFound = errors.New("tx not found")
+	// 事务日志的状态与预期不符，例如重复提交了相反的事务状态. TXStore 的实现需要包装该错误
+	ErrStoreConflict = errors.New("txstore conflict")
+	// TXStore 的锁已被其他节点持有. TXStore.Lock 的实现需要包装该错误
+	ErrLockHeld = errors.New("txstore lock held")
+	// TXStore 未实现所需的扩展能力
+	ErrStoreUnsupported = errors.New("txstore unsupported")
+	// 编解码方式未注册
+	ErrCodecNotFound = errors.New("codec not found")
+	// 幂等键已存在，TXDetailStore.CreateTXDetail 需要返回该错误
+	ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
+)
+
+// 与具体组件相关的错误，可以通过 errors.As 获取出错的组件以及所处的阶段
+type ComponentError struct {
+	ComponentID string
+	// 出错时所处的阶段，与具体阶段无关时为空
+	Phase Phase
+	Err   error
+}
+
+func (c *ComponentError) Error() string {
+	if c.Phase == "" {
+		return fmt.Sprintf("component: %s, err: %v", c.ComponentID, c.Err)
+	}
+	return fmt.Sprintf("component: %s, phase: %s, err: %v", c.ComponentID, c.Phase, c.Err)
+}
+
+func (c *ComponentError) Unwrap() error {
+	return c.Err
+}
+
+func componentError(componentID string, phase Phase, err error) error {
+	return &ComponentError{
+		ComponentID: componentID,
+		Phase:       phase,
+		Err:         err,
+	}
+}
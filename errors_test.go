@@ -0,0 +1,57 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_errors(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore())
+	defer txManager.Stop()
+	ctx := context.Background()
+	schema := NewSchema(1, SchemaField{Name: "biz_id", Type: FieldString, Required: true})
+	assert.Equal(t, nil, txManager.Register(newMockComponent("a"), WithSchema(schema)))
+	assert.Equal(t, nil, txManager.Register(newMockComponent("b")))
+
+	var componentErr *ComponentError
+	err := txManager.Register(newMockComponent("a"))
+	assert.Equal(t, true, errors.Is(err, ErrDuplicateComponent))
+	assert.Equal(t, true, errors.As(err, &componentErr))
+	assert.Equal(t, "a", componentErr.ComponentID)
+
+	err = txManager.Replace(newMockComponent("c"))
+	assert.Equal(t, true, errors.Is(err, ErrComponentNotFound))
+	err = txManager.Replace(newMockComponent("b"), WithVersion(1))
+	assert.Equal(t, nil, err)
+	err = txManager.Replace(newMockComponent("b"), WithVersion(1))
+	assert.Equal(t, true, errors.Is(err, ErrStaleVersion))
+
+	tests := []struct {
+		name   string
+		reqs   []*RequestEntity
+		target error
+	}{
+		{name: "empty", target: ErrEmptyTransaction},
+		{name: "not found", reqs: []*RequestEntity{{ComponentID: "c"}}, target: ErrComponentNotFound},
+		{name: "duplicate", reqs: []*RequestEntity{{ComponentID: "b"}, {ComponentID: "b"}}, target: ErrDuplicateComponent},
+		{name: "invalid request", reqs: []*RequestEntity{{ComponentID: "a"}}, target: ErrInvalidRequest},
+		{name: "invalid dependency", reqs: []*RequestEntity{{ComponentID: "b", DependsOn: []string{"c"}}}, target: ErrInvalidDependency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := txManager.Transaction(ctx, tt.reqs)
+			assert.Equal(t, true, errors.Is(err, tt.target), err)
+		})
+	}
+
+	// try 阶段的错误记录在事务执行结果中
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "b", Request: map[string]interface{}{"reject_flag": true}}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, errors.Is(result.Err, ErrTryRejected))
+	assert.Equal(t, true, errors.As(result.Err, &componentErr))
+	assert.Equal(t, "b", componentErr.ComponentID)
+	assert.Equal(t, PhaseTry, componentErr.Phase)
+}
@@ -71,7 +71,7 @@ func (t *TXRecordDAO) UpdateComponentStatus(ctx context.Context, id uint, compon
 			return dao.UpdateTXRecord(ctx, record)
 		}
 
-		return fmt.Errorf("invalid status: %s of component: %s, txid: %d", statuses[componentID].TryStatus, componentID, id)
+		return fmt.Errorf("%w: invalid status: %s of component: %s, txid: %d", gotcc.ErrStoreConflict, statuses[componentID].TryStatus, componentID, id)
 	})
 }
 
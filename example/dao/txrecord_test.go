@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -235,7 +236,7 @@ func Test_UpdateComponentStatus(t *testing.T) {
 
 				mock.ExpectCommit()
 				err := txRecordDAO.UpdateComponentStatus(ctx, 1, "component_a", gotcc.TrySucceesful.String())
-				assert.Equal(t, true, err != nil)
+				assert.Equal(t, true, errors.Is(err, gotcc.ErrStoreConflict))
 			},
 		},
 	}
@@ -59,7 +59,7 @@ func main() {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 	defer cancel()
-	_, success, err := txManager.Transaction(ctx, []*gotcc.RequestEntity{
+	result, err := txManager.Transaction(ctx, []*gotcc.RequestEntity{
 		{ComponentID: componentAID,
 			Request: map[string]interface{}{
 				"biz_id": componentAID + "_biz",
@@ -75,13 +75,13 @@ func main() {
 				"biz_id": componentCID + "_biz",
 			},
 		},
-	}...)
+	})
 	if err != nil {
 		fmt.Printf("tx failed, err: %v", err)
 		return
 	}
-	if !success {
-		fmt.Println("tx failed")
+	if !result.Success {
+		fmt.Printf("tx failed, err: %v", result.Err)
 		return
 	}
 
@@ -89,7 +89,11 @@ func (m *MockTXStore) GetHangingTXs(ctx context.Context) ([]*gotcc.Transaction,
 
 func (m *MockTXStore) Lock(ctx context.Context, expireDuration time.Duration) error {
 	lock := redis_lock.NewRedisLock(pkg.BuildTXRecordLockKey(), m.client, redis_lock.WithExpireSeconds(int64(expireDuration.Seconds())))
-	return lock.Lock(ctx)
+	err := lock.Lock(ctx)
+	if errors.Is(err, redis_lock.ErrLockAcquiredByOthers) {
+		return fmt.Errorf("%w: %w", gotcc.ErrLockHeld, err)
+	}
+	return err
 }
 
 func (m *MockTXStore) Unlock(ctx context.Context) error {
@@ -102,12 +106,12 @@ func (m *MockTXStore) TXSubmit(ctx context.Context, txID string, success bool) e
 	do := func(ctx context.Context, dao *expdao.TXRecordDAO, record *expdao.TXRecordPO) error {
 		if success {
 			if record.Status == gotcc.TXFailure.String() {
-				return fmt.Errorf("invalid tx status: %s, txid: %s", record.Status, txID)
+				return fmt.Errorf("%w: invalid tx status: %s, txid: %s", gotcc.ErrStoreConflict, record.Status, txID)
 			}
 			record.Status = gotcc.TXSuccessful.String()
 		} else {
 			if record.Status == gotcc.TXSuccessful.String() {
-				return fmt.Errorf("invalid tx status: %s, txid: %s", record.Status, txID)
+				return fmt.Errorf("%w: invalid tx status: %s, txid: %s", gotcc.ErrStoreConflict, record.Status, txID)
 			}
 			record.Status = gotcc.TXFailure.String()
 		}
@@ -123,7 +127,7 @@ func (m *MockTXStore) GetTX(ctx context.Context, txID string) (*gotcc.Transactio
 		return nil, err
 	}
 	if len(records) != 1 {
-		return nil, errors.New("get tx failed")
+		return nil, fmt.Errorf("%w: txid: %s", gotcc.ErrTXNotFound, txID)
 	}
 
 	componentTryStatuses := make(map[string]*expdao.ComponentTryStatus)
@@ -0,0 +1,145 @@
+package grpctransport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/grpctransport/pb"
+)
+
+type ClientOptions struct {
+	// 单次请求的超时时长，可选. 指定时作为请求时长的上限，否则以调用方 ctx 的 deadline 为准
+	Timeout time.Duration
+	// 链路追踪模块，用于把链路信息透传给远端
+	Tracer gotcc.Tracer
+	// 透传给底层 grpc 调用的参数
+	CallOptions []grpc.CallOption
+}
+
+type ClientOption func(*ClientOptions)
+
+func WithClientTimeout(timeout time.Duration) ClientOption {
+	return func(o *ClientOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithClientTracer(tracer gotcc.Tracer) ClientOption {
+	return func(o *ClientOptions) {
+		o.Tracer = tracer
+	}
+}
+
+func WithCallOptions(callOpts ...grpc.CallOption) ClientOption {
+	return func(o *ClientOptions) {
+		o.CallOptions = append(o.CallOptions, callOpts...)
+	}
+}
+
+// 远端 tcc 参与方的 grpc client，实现了 gotcc.TCCComponent
+type Client struct {
+	id     string
+	client pb.TCCServiceClient
+	opts   *ClientOptions
+}
+
+func NewClient(componentID string, conn grpc.ClientConnInterface, opts ...ClientOption) *Client {
+	c := Client{
+		id:     componentID,
+		client: pb.NewTCCServiceClient(conn),
+		opts:   &ClientOptions{},
+	}
+	for _, opt := range opts {
+		opt(c.opts)
+	}
+	return &c
+}
+
+func (c *Client) ID() string {
+	return c.id
+}
+
+func (c *Client) Try(ctx context.Context, req *gotcc.TCCReq) (*gotcc.TCCResp, error) {
+	data, err := toStruct(req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("component: %s try encode req failed, err: %w", c.id, err)
+	}
+	return c.do(ctx, gotcc.PhaseTry, req.TXID, func(ctx context.Context) (*pb.TCCResponse, error) {
+		return c.client.Try(ctx, &pb.TryRequest{
+			ComponentId: c.id,
+			TxId:        req.TXID,
+			Data:        data,
+			Metadata:    req.Metadata,
+		}, c.opts.CallOptions...)
+	})
+}
+
+func (c *Client) Confirm(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	return c.do(ctx, gotcc.PhaseConfirm, txID, func(ctx context.Context) (*pb.TCCResponse, error) {
+		return c.client.Confirm(ctx, &pb.PhaseTwoRequest{ComponentId: c.id, TxId: txID}, c.opts.CallOptions...)
+	})
+}
+
+func (c *Client) Cancel(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	return c.do(ctx, gotcc.PhaseCancel, txID, func(ctx context.Context) (*pb.TCCResponse, error) {
+		return c.client.Cancel(ctx, &pb.PhaseTwoRequest{ComponentId: c.id, TxId: txID}, c.opts.CallOptions...)
+	})
+}
+
+func (c *Client) do(ctx context.Context, phase gotcc.Phase, txID string, call func(ctx context.Context) (*pb.TCCResponse, error)) (*gotcc.TCCResp, error) {
+	// deadline 会随 grpc 请求透传给远端
+	ctx, cancel := withTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	carrier := make(map[string]string)
+	if c.opts.Tracer != nil {
+		c.opts.Tracer.Inject(ctx, carrier)
+	}
+	resp, err := call(outgoingContext(ctx, txID, c.id, carrier))
+
+	// FailedPrecondition 代表远端明确拒绝，以状态信息作为拒绝原因
+	if s, ok := status.FromError(err); ok && s.Code() == codes.FailedPrecondition {
+		return &gotcc.TCCResp{
+			ComponentID: c.id,
+			TXID:        txID,
+			Reason: &gotcc.RejectReason{
+				Code:    s.Code().String(),
+				Message: s.Message(),
+			},
+		}, nil
+	}
+	// 其余错误代表远端处理异常，交由事务协调器重试
+	if err != nil {
+		return nil, fmt.Errorf("component: %s %s failed, err: %w", c.id, phase, err)
+	}
+
+	return &gotcc.TCCResp{
+		ComponentID: resp.GetComponentId(),
+		TXID:        resp.GetTxId(),
+		ACK:         resp.GetAck(),
+		Result:      fromStruct(resp.GetResult()),
+		Reason:      fromReason(resp.GetReason()),
+		Pending:     resp.GetPending(),
+	}, nil
+}
+
+// 默认的请求时长，仅在既未显式指定超时时长、ctx 也没有 deadline 时使用
+const defaultTimeout = 5 * time.Second
+
+// 显式指定了超时时长时以其作为上限，否则沿用 ctx 的 deadline，例如 TXManager 为各组件设置的超时时长.
+// ctx 没有 deadline 时使用默认时长
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		if _, ok := ctx.Deadline(); ok {
+			return context.WithCancel(ctx)
+		}
+		timeout = defaultTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,4 @@
+// pb 为 tcc grpc 协议生成的代码，修改 tcc.proto 后需要重新生成
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tcc.proto
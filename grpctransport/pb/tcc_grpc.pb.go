@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.1
+// source: tcc.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TCCService_Try_FullMethodName     = "/gotcc.transport.v1.TCCService/Try"
+	TCCService_Confirm_FullMethodName = "/gotcc.transport.v1.TCCService/Confirm"
+	TCCService_Cancel_FullMethodName  = "/gotcc.transport.v1.TCCService/Cancel"
+)
+
+// TCCServiceClient is the client API for TCCService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// tcc 参与方对外暴露的 grpc 服务
+type TCCServiceClient interface {
+	// 第一阶段 try 操作
+	Try(ctx context.Context, in *TryRequest, opts ...grpc.CallOption) (*TCCResponse, error)
+	// 第二阶段 confirm 操作
+	Confirm(ctx context.Context, in *PhaseTwoRequest, opts ...grpc.CallOption) (*TCCResponse, error)
+	// 第二阶段 cancel 操作
+	Cancel(ctx context.Context, in *PhaseTwoRequest, opts ...grpc.CallOption) (*TCCResponse, error)
+}
+
+type tCCServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTCCServiceClient(cc grpc.ClientConnInterface) TCCServiceClient {
+	return &tCCServiceClient{cc}
+}
+
+func (c *tCCServiceClient) Try(ctx context.Context, in *TryRequest, opts ...grpc.CallOption) (*TCCResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TCCResponse)
+	err := c.cc.Invoke(ctx, TCCService_Try_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tCCServiceClient) Confirm(ctx context.Context, in *PhaseTwoRequest, opts ...grpc.CallOption) (*TCCResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TCCResponse)
+	err := c.cc.Invoke(ctx, TCCService_Confirm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tCCServiceClient) Cancel(ctx context.Context, in *PhaseTwoRequest, opts ...grpc.CallOption) (*TCCResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TCCResponse)
+	err := c.cc.Invoke(ctx, TCCService_Cancel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TCCServiceServer is the server API for TCCService service.
+// All implementations must embed UnimplementedTCCServiceServer
+// for forward compatibility.
+//
+// tcc 参与方对外暴露的 grpc 服务
+type TCCServiceServer interface {
+	// 第一阶段 try 操作
+	Try(context.Context, *TryRequest) (*TCCResponse, error)
+	// 第二阶段 confirm 操作
+	Confirm(context.Context, *PhaseTwoRequest) (*TCCResponse, error)
+	// 第二阶段 cancel 操作
+	Cancel(context.Context, *PhaseTwoRequest) (*TCCResponse, error)
+	mustEmbedUnimplementedTCCServiceServer()
+}
+
+// UnimplementedTCCServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTCCServiceServer struct{}
+
+func (UnimplementedTCCServiceServer) Try(context.Context, *TryRequest) (*TCCResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Try not implemented")
+}
+func (UnimplementedTCCServiceServer) Confirm(context.Context, *PhaseTwoRequest) (*TCCResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Confirm not implemented")
+}
+func (UnimplementedTCCServiceServer) Cancel(context.Context, *PhaseTwoRequest) (*TCCResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cancel not implemented")
+}
+func (UnimplementedTCCServiceServer) mustEmbedUnimplementedTCCServiceServer() {}
+func (UnimplementedTCCServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeTCCServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TCCServiceServer will
+// result in compilation errors.
+type UnsafeTCCServiceServer interface {
+	mustEmbedUnimplementedTCCServiceServer()
+}
+
+func RegisterTCCServiceServer(s grpc.ServiceRegistrar, srv TCCServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTCCServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TCCService_ServiceDesc, srv)
+}
+
+func _TCCService_Try_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TCCServiceServer).Try(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TCCService_Try_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TCCServiceServer).Try(ctx, req.(*TryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TCCService_Confirm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PhaseTwoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TCCServiceServer).Confirm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TCCService_Confirm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TCCServiceServer).Confirm(ctx, req.(*PhaseTwoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TCCService_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PhaseTwoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TCCServiceServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TCCService_Cancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TCCServiceServer).Cancel(ctx, req.(*PhaseTwoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TCCService_ServiceDesc is the grpc.ServiceDesc for TCCService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TCCService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gotcc.transport.v1.TCCService",
+	HandlerType: (*TCCServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Try",
+			Handler:    _TCCService_Try_Handler,
+		},
+		{
+			MethodName: "Confirm",
+			Handler:    _TCCService_Confirm_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _TCCService_Cancel_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "tcc.proto",
+}
@@ -0,0 +1,103 @@
+package grpctransport
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/grpctransport/pb"
+)
+
+// grpc 的 tcc 协议约定:
+//  1. 服务定义见 pb/tcc.proto，try/confirm/cancel 分别对应 TCCService 的三个方法
+//  2. 事务 id、组件 id 以及链路信息通过 grpc metadata 透传
+//  3. 请求的超时时长由 grpc deadline 透传，服务端据此限制处理时长
+//  4. 参与方明确拒绝时返回 TCCResponse.ack = false，拒绝原因通过 TCCResponse.reason 透传；
+//     返回 FailedPrecondition 状态码同样视为明确拒绝，其余错误由事务协调器后续重试
+const (
+	// 事务 id
+	MetadataTXID = "x-gotcc-txid"
+	// 组件 id
+	MetadataComponentID = "x-gotcc-component-id"
+	// 链路信息的 metadata 前缀
+	MetadataTracePrefix = "x-gotcc-trace-"
+)
+
+// 把事务 id、组件 id 以及链路信息写入 outgoing metadata
+func outgoingContext(ctx context.Context, txID, componentID string, carrier map[string]string) context.Context {
+	kv := []string{MetadataTXID, txID, MetadataComponentID, componentID}
+	for k, v := range carrier {
+		kv = append(kv, MetadataTracePrefix+strings.ToLower(k), v)
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// 从 incoming metadata 中获取事务 id
+func txIDFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vs := md.Get(MetadataTXID); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// 从 incoming metadata 中提取链路信息
+func extractTrace(ctx context.Context) map[string]string {
+	carrier := make(map[string]string)
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, vs := range md {
+		if !strings.HasPrefix(k, MetadataTracePrefix) || len(vs) == 0 {
+			continue
+		}
+		carrier[strings.TrimPrefix(k, MetadataTracePrefix)] = vs[0]
+	}
+	return carrier
+}
+
+// 业务请求参数与 protobuf Struct 之间的转换，统一按照 json 语义处理
+func toStruct(data map[string]interface{}) (*structpb.Struct, error) {
+	if data == nil {
+		return nil, nil
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var s structpb.Struct
+	if err = protojson.Unmarshal(body, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func fromStruct(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+func toReason(reason *gotcc.RejectReason) *pb.RejectReason {
+	if reason == nil {
+		return nil
+	}
+	return &pb.RejectReason{
+		Code:    reason.Code,
+		Message: reason.Message,
+	}
+}
+
+func fromReason(reason *pb.RejectReason) *gotcc.RejectReason {
+	if reason == nil {
+		return nil
+	}
+	return &gotcc.RejectReason{
+		Code:    reason.GetCode(),
+		Message: reason.GetMessage(),
+	}
+}
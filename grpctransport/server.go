@@ -0,0 +1,130 @@
+package grpctransport
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/grpctransport/pb"
+)
+
+type ServerOptions struct {
+	// 单次请求的最大处理时长，可选. 未指定时以调用方透传的 deadline 为准，调用方透传的 deadline 更早时同样以调用方为准
+	Timeout time.Duration
+	// 链路追踪模块，用于延续远端透传过来的链路
+	Tracer gotcc.Tracer
+}
+
+type ServerOption func(*ServerOptions)
+
+func WithServerTimeout(timeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithServerTracer(tracer gotcc.Tracer) ServerOption {
+	return func(o *ServerOptions) {
+		o.Tracer = tracer
+	}
+}
+
+// 把本地的 tcc 组件以 grpc 协议暴露出去，实现了 pb.TCCServiceServer
+type Server struct {
+	pb.UnimplementedTCCServiceServer
+	component gotcc.TCCComponent
+	opts      *ServerOptions
+}
+
+func NewServer(component gotcc.TCCComponent, opts ...ServerOption) *Server {
+	s := Server{
+		component: component,
+		opts:      &ServerOptions{},
+	}
+	for _, opt := range opts {
+		opt(s.opts)
+	}
+	return &s
+}
+
+// Register 把 tcc 服务注册到 grpc server 上
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	pb.RegisterTCCServiceServer(registrar, s)
+}
+
+func (s *Server) Try(ctx context.Context, req *pb.TryRequest) (*pb.TCCResponse, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	if s.opts.Tracer != nil && len(req.GetMetadata()) > 0 {
+		ctx = s.opts.Tracer.Extract(ctx, req.GetMetadata())
+	}
+	resp, err := s.component.Try(ctx, &gotcc.TCCReq{
+		ComponentID: req.GetComponentId(),
+		TXID:        s.txID(ctx, req.GetTxId()),
+		Data:        fromStruct(req.GetData()),
+		Metadata:    req.GetMetadata(),
+	})
+	return toResponse(resp, err)
+}
+
+func (s *Server) Confirm(ctx context.Context, req *pb.PhaseTwoRequest) (*pb.TCCResponse, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	return toResponse(s.component.Confirm(ctx, s.txID(ctx, req.GetTxId())))
+}
+
+func (s *Server) Cancel(ctx context.Context, req *pb.PhaseTwoRequest) (*pb.TCCResponse, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	return toResponse(s.component.Cancel(ctx, s.txID(ctx, req.GetTxId())))
+}
+
+// 限制处理时长，并延续 metadata 中透传过来的链路
+func (s *Server) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := withTimeout(ctx, s.opts.Timeout)
+	if s.opts.Tracer != nil {
+		ctx = s.opts.Tracer.Extract(ctx, extractTrace(ctx))
+	}
+	return ctx, cancel
+}
+
+// 请求体中未携带事务 id 时，使用 metadata 中透传的事务 id
+func (s *Server) txID(ctx context.Context, txID string) string {
+	if txID != "" {
+		return txID
+	}
+	return txIDFromContext(ctx)
+}
+
+func toResponse(resp *gotcc.TCCResp, err error) (*pb.TCCResponse, error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if err != nil {
+		// 组件自身返回的 grpc 状态码原样透传，例如以 FailedPrecondition 明确拒绝
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if resp == nil {
+		return nil, status.Error(codes.Internal, "empty resp")
+	}
+	result, err := toStruct(resp.Result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.TCCResponse{
+		ComponentId: resp.ComponentID,
+		TxId:        resp.TXID,
+		Ack:         resp.ACK,
+		Result:      result,
+		Reason:      toReason(resp.Reason),
+		Pending:     resp.Pending,
+	}, nil
+}
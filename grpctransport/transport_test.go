@@ -0,0 +1,211 @@
+package grpctransport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+)
+
+type mockComponent struct {
+	id       string
+	mutex    sync.Mutex
+	deadline time.Duration
+	txID     string
+	data     map[string]interface{}
+}
+
+func (m *mockComponent) ID() string {
+	return m.id
+}
+
+func (m *mockComponent) Try(ctx context.Context, req *gotcc.TCCReq) (*gotcc.TCCResp, error) {
+	m.mutex.Lock()
+	m.data = req.Data
+	m.mutex.Unlock()
+
+	switch req.Data["flag"] {
+	case "reject":
+		return &gotcc.TCCResp{ComponentID: m.id, TXID: req.TXID, Reason: &gotcc.RejectReason{Code: "out_of_stock"}}, nil
+	case "err":
+		return nil, errors.New("try err")
+	case "slow":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: req.TXID, ACK: true, Result: map[string]interface{}{"reservation": req.TXID}}, nil
+}
+
+func (m *mockComponent) Confirm(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.txID = txIDFromContext(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		m.deadline = time.Until(deadline)
+	}
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: txID, ACK: true}, nil
+}
+
+func (m *mockComponent) Cancel(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	return nil, status.Error(codes.FailedPrecondition, "reject")
+}
+
+func newTestClient(t *testing.T, component gotcc.TCCComponent, opts ...ClientOption) (*Client, func()) {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	NewServer(component).Register(server)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(component.ID(), conn, opts...), func() {
+		_ = conn.Close()
+		server.Stop()
+	}
+}
+
+func Test_Transport(t *testing.T) {
+	component := mockComponent{id: "a"}
+	client, stop := newTestClient(t, &component, WithClientTimeout(200*time.Millisecond))
+	defer stop()
+	assert.Equal(t, "a", client.ID())
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		txID      string
+		flag      string
+		expectErr bool
+		ack       bool
+	}{
+		{name: "ack", txID: "1", ack: true},
+		{name: "reject", txID: "2", flag: "reject"},
+		{name: "err", txID: "3", flag: "err", expectErr: true},
+		{name: "timeout", txID: "4", flag: "slow", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Try(ctx, &gotcc.TCCReq{
+				ComponentID: "a",
+				TXID:        tt.txID,
+				Data:        map[string]interface{}{"flag": tt.flag, "amount": 1},
+			})
+			assert.Equal(t, tt.expectErr, err != nil)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.ack, resp.ACK)
+			assert.Equal(t, tt.txID, resp.TXID)
+			if tt.ack {
+				assert.Equal(t, map[string]interface{}{"reservation": tt.txID}, resp.Result)
+			} else {
+				assert.Equal(t, &gotcc.RejectReason{Code: "out_of_stock"}, resp.Reason)
+			}
+		})
+	}
+	component.mutex.Lock()
+	assert.Equal(t, float64(1), component.data["amount"])
+	component.mutex.Unlock()
+
+	// txID 与 deadline 随请求透传给远端
+	resp, err := client.Confirm(ctx, "1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, resp.ACK)
+	component.mutex.Lock()
+	assert.Equal(t, "1", component.txID)
+	assert.Equal(t, true, component.deadline > 0 && component.deadline <= 200*time.Millisecond)
+	component.mutex.Unlock()
+
+	// FailedPrecondition 视为明确拒绝
+	resp, err = client.Cancel(ctx, "2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, resp.ACK)
+	assert.Equal(t, "reject", resp.Reason.Message)
+}
+
+type metadataTracer struct{}
+
+func (metadataTracer) Start(ctx context.Context, name string, opts ...gotcc.SpanOption) (context.Context, gotcc.Span) {
+	return ctx, nil
+}
+
+func (metadataTracer) Inject(ctx context.Context, carrier map[string]string) {
+	carrier["traceparent"] = "trace"
+}
+
+func (metadataTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return ctx
+}
+
+func Test_Transport_trace(t *testing.T) {
+	var carrier map[string]string
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		carrier = extractTrace(ctx)
+		return handler(ctx, req)
+	}))
+	NewServer(&mockComponent{id: "a"}).Register(server)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	_, err = NewClient("a", conn, WithClientTracer(metadataTracer{})).Confirm(context.Background(), "1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"traceparent": "trace"}, carrier)
+}
+
+func Test_Transport_deadline(t *testing.T) {
+	component := mockComponent{id: "a"}
+	client, stop := newTestClient(t, &component)
+	defer stop()
+
+	// 未指定超时时长时沿用调用方 ctx 的 deadline，不会被截断为默认时长
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := client.Confirm(ctx, "1")
+	assert.Equal(t, nil, err)
+	component.mutex.Lock()
+	assert.Equal(t, true, component.deadline > defaultTimeout)
+	component.mutex.Unlock()
+
+	// ctx 没有 deadline 时使用默认时长
+	_, err = client.Confirm(context.Background(), "1")
+	assert.Equal(t, nil, err)
+	component.mutex.Lock()
+	assert.Equal(t, true, component.deadline > 0 && component.deadline <= defaultTimeout)
+	component.mutex.Unlock()
+}
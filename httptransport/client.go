@@ -0,0 +1,195 @@
+package httptransport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+)
+
+type ClientOptions struct {
+	// 底层的 http client
+	HTTPClient *http.Client
+	// 单次请求的超时时长，可选. 指定时作为请求时长的上限，否则以调用方 ctx 的 deadline 为准
+	Timeout time.Duration
+	// 链路追踪模块，用于把链路信息透传给远端
+	Tracer gotcc.Tracer
+	// 请求体的编解码方式，默认为 json
+	Codec gotcc.Codec
+}
+
+type ClientOption func(*ClientOptions)
+
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(o *ClientOptions) {
+		o.HTTPClient = client
+	}
+}
+
+func WithClientTimeout(timeout time.Duration) ClientOption {
+	return func(o *ClientOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithClientTracer(tracer gotcc.Tracer) ClientOption {
+	return func(o *ClientOptions) {
+		o.Tracer = tracer
+	}
+}
+
+func WithClientCodec(codec gotcc.Codec) ClientOption {
+	return func(o *ClientOptions) {
+		o.Codec = codec
+	}
+}
+
+func repairClientOptions(o *ClientOptions) {
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	if o.Codec == nil {
+		o.Codec = gotcc.JSONCodec{}
+	}
+}
+
+// 远端 tcc 参与方的 http client，实现了 gotcc.TCCComponent
+type Client struct {
+	id      string
+	baseURL string
+	opts    *ClientOptions
+}
+
+func NewClient(componentID, baseURL string, opts ...ClientOption) *Client {
+	c := Client{
+		id:      componentID,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		opts:    &ClientOptions{},
+	}
+	for _, opt := range opts {
+		opt(c.opts)
+	}
+	repairClientOptions(c.opts)
+	return &c
+}
+
+func (c *Client) ID() string {
+	return c.id
+}
+
+// 远端参与方的地址
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+func (c *Client) Try(ctx context.Context, req *gotcc.TCCReq) (*gotcc.TCCResp, error) {
+	return c.do(ctx, gotcc.PhaseTry, req.TXID, req)
+}
+
+func (c *Client) Confirm(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	return c.do(ctx, gotcc.PhaseConfirm, txID, &PhaseTwoReq{ComponentID: c.id, TXID: txID})
+}
+
+func (c *Client) Cancel(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	return c.do(ctx, gotcc.PhaseCancel, txID, &PhaseTwoReq{ComponentID: c.id, TXID: txID})
+}
+
+func (c *Client) do(ctx context.Context, phase gotcc.Phase, txID string, body interface{}) (*gotcc.TCCResp, error) {
+	ctx, cancel := withTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	reqBody, err := c.opts.Codec.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+phasePath(phase), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", contentType(c.opts.Codec))
+	httpReq.Header.Set(HeaderProtocolVersion, strconv.Itoa(ProtocolVersion))
+	httpReq.Header.Set(HeaderIdempotencyKey, idempotencyKey(txID, c.id, phase))
+	httpReq.Header.Set(HeaderTXID, txID)
+	httpReq.Header.Set(HeaderComponentID, c.id)
+	if deadline, ok := ctx.Deadline(); ok {
+		httpReq.Header.Set(HeaderTimeout, strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+	}
+	if c.opts.Tracer != nil {
+		carrier := make(map[string]string)
+		c.opts.Tracer.Inject(ctx, carrier)
+		injectTrace(httpReq.Header, carrier)
+	}
+
+	httpResp, err := c.opts.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 响应体的编解码方式以响应的 Content-Type 为准
+	codec, err := codecOf(httpResp.Header)
+	if err != nil {
+		return nil, fmt.Errorf("component: %s %s decode resp failed, err: %w", c.id, phase, err)
+	}
+
+	// 5xx 代表远端处理异常，交由事务协调器重试
+	if httpResp.StatusCode >= http.StatusInternalServerError {
+		var errResp ErrorResp
+		_ = codec.Unmarshal(respBody, &errResp)
+		return nil, fmt.Errorf("component: %s %s failed, status: %d, message: %s", c.id, phase, httpResp.StatusCode, errResp.Message)
+	}
+
+	// 4xx 代表远端明确拒绝
+	if httpResp.StatusCode >= http.StatusBadRequest {
+		var errResp ErrorResp
+		_ = codec.Unmarshal(respBody, &errResp)
+		return &gotcc.TCCResp{
+			ComponentID: c.id,
+			TXID:        txID,
+			Reason: &gotcc.RejectReason{
+				Code:    errResp.Code,
+				Message: errResp.Message,
+			},
+		}, nil
+	}
+
+	var resp gotcc.TCCResp
+	if err = codec.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("component: %s %s decode resp failed, err: %w", c.id, phase, err)
+	}
+	return &resp, nil
+}
+
+// 基于注册中心中的访问地址构造 http client，用于 gotcc.WithRegistry
+func Dialer(opts ...ClientOption) gotcc.Dialer {
+	return func(endpoint *gotcc.ComponentEndpoint) (gotcc.TCCComponent, error) {
+		return NewClient(endpoint.ComponentID, endpoint.Endpoint, opts...), nil
+	}
+}
+
+// 默认的请求时长，仅在既未显式指定超时时长、ctx 也没有 deadline 时使用
+const defaultTimeout = 5 * time.Second
+
+// 显式指定了超时时长时以其作为上限，否则沿用 ctx 的 deadline，例如 TXManager 为各组件设置的超时时长.
+// ctx 没有 deadline 时使用默认时长
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		if _, ok := ctx.Deadline(); ok {
+			return context.WithCancel(ctx)
+		}
+		timeout = defaultTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
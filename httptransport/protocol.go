@@ -0,0 +1,119 @@
+package httptransport
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+)
+
+// json over http 的 tcc 协议约定:
+//  1. try 请求: POST {baseURL}/try，请求体为 gotcc.TCCReq
+//  2. confirm 请求: POST {baseURL}/confirm，请求体为 PhaseTwoReq
+//  3. cancel 请求: POST {baseURL}/cancel，请求体为 PhaseTwoReq
+//  4. 成功响应: 200，响应体为 gotcc.TCCResp. try 响应的 pending 为 true 时代表参与方仍在异步处理，
+//     得出结果后需要回调 TXManager.ReportTry，例如通过事务协调器的 /transactions/report 接口
+//  5. 错误响应: 响应体为 ErrorResp. 4xx 代表参与方明确拒绝，映射为 TCCResp.ACK = false，并以 ErrorResp 作为拒绝原因；
+//     5xx 代表参与方处理异常，映射为 error，由事务协调器后续重试
+//  6. 请求体与响应体的编解码方式由 Content-Type 决定，默认为 json. 其余编解码方式的 Content-Type 为
+//     application/vnd.gotcc+{codec}，服务端需要通过 gotcc.RegisterCodec 注册对应的编解码方式
+//  7. 协议版本号通过 X-Gotcc-Protocol-Version 透传，服务端拒绝高于自身版本的请求
+const (
+	PathTry     = "/try"
+	PathConfirm = "/confirm"
+	PathCancel  = "/cancel"
+)
+
+const (
+	// 幂等键，同一个键的重复请求会得到相同的响应
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// 事务 id
+	HeaderTXID = "X-Gotcc-TXID"
+	// 组件 id
+	HeaderComponentID = "X-Gotcc-Component-ID"
+	// 请求剩余的超时时长，单位为毫秒，服务端据此限制处理时长
+	HeaderTimeout = "X-Gotcc-Timeout"
+	// 链路信息的 header 前缀
+	HeaderTracePrefix = "X-Gotcc-Trace-"
+	// 协议版本号
+	HeaderProtocolVersion = "X-Gotcc-Protocol-Version"
+)
+
+// 当前的协议版本号
+const ProtocolVersion = 1
+
+const (
+	contentTypeJSON        = "application/json"
+	contentTypeCodecPrefix = "application/vnd.gotcc+"
+)
+
+// 第二阶段 confirm/cancel 请求参数
+type PhaseTwoReq struct {
+	ComponentID string `json:"componentID"`
+	TXID        string `json:"txID"`
+}
+
+// 错误响应
+type ErrorResp struct {
+	// 错误码，可选. 4xx 响应时作为拒绝原因的原因码
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// 基于阶段获取请求路径
+func phasePath(phase gotcc.Phase) string {
+	switch phase {
+	case gotcc.PhaseTry:
+		return PathTry
+	case gotcc.PhaseConfirm:
+		return PathConfirm
+	default:
+		return PathCancel
+	}
+}
+
+// 构造幂等键
+func idempotencyKey(txID, componentID string, phase gotcc.Phase) string {
+	return txID + ":" + componentID + ":" + phase.String()
+}
+
+// 把链路信息写入 header
+func injectTrace(header http.Header, carrier map[string]string) {
+	for k, v := range carrier {
+		header.Set(HeaderTracePrefix+k, v)
+	}
+}
+
+// 从 header 中提取链路信息
+func extractTrace(header http.Header) map[string]string {
+	carrier := make(map[string]string)
+	for k, vs := range header {
+		if !strings.HasPrefix(k, HeaderTracePrefix) || len(vs) == 0 {
+			continue
+		}
+		carrier[strings.ToLower(strings.TrimPrefix(k, HeaderTracePrefix))] = vs[0]
+	}
+	return carrier
+}
+
+// 基于编解码方式获取 Content-Type
+func contentType(codec gotcc.Codec) string {
+	if codec.Name() == (gotcc.JSONCodec{}).Name() {
+		return contentTypeJSON
+	}
+	return contentTypeCodecPrefix + codec.Name()
+}
+
+// 基于 Content-Type 获取编解码方式，未指定时使用 json
+func codecOf(header http.Header) (gotcc.Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType == contentTypeJSON {
+		return gotcc.JSONCodec{}, nil
+	}
+	if !strings.HasPrefix(mediaType, contentTypeCodecPrefix) {
+		return nil, fmt.Errorf("unsupported content type: %s", mediaType)
+	}
+	return gotcc.GetCodec(strings.TrimPrefix(mediaType, contentTypeCodecPrefix))
+}
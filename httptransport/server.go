@@ -0,0 +1,228 @@
+package httptransport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+)
+
+type HandlerOptions struct {
+	// 单次请求的最大处理时长，可选. 未指定时以调用方透传的超时时长为准
+	Timeout time.Duration
+	// 幂等键对应响应结果的缓存时长，小于等于 0 时不缓存
+	IdempotencyTTL time.Duration
+	// 链路追踪模块，用于延续远端透传过来的链路
+	Tracer gotcc.Tracer
+}
+
+type HandlerOption func(*HandlerOptions)
+
+func WithHandlerTimeout(timeout time.Duration) HandlerOption {
+	return func(o *HandlerOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithIdempotencyTTL(ttl time.Duration) HandlerOption {
+	return func(o *HandlerOptions) {
+		o.IdempotencyTTL = ttl
+	}
+}
+
+func WithHandlerTracer(tracer gotcc.Tracer) HandlerOption {
+	return func(o *HandlerOptions) {
+		o.Tracer = tracer
+	}
+}
+
+// 把本地的 tcc 组件以 json over http 协议暴露出去
+type Handler struct {
+	component gotcc.TCCComponent
+	opts      *HandlerOptions
+	mux       *http.ServeMux
+	cache     *idempotencyCache
+}
+
+func NewHandler(component gotcc.TCCComponent, opts ...HandlerOption) *Handler {
+	h := Handler{
+		component: component,
+		opts: &HandlerOptions{
+			IdempotencyTTL: time.Minute,
+		},
+		mux: http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(h.opts)
+	}
+	h.cache = newIdempotencyCache(h.opts.IdempotencyTTL)
+
+	h.mux.HandleFunc(PathTry, h.handle(gotcc.PhaseTry))
+	h.mux.HandleFunc(PathConfirm, h.handle(gotcc.PhaseConfirm))
+	h.mux.HandleFunc(PathCancel, h.handle(gotcc.PhaseCancel))
+	return &h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handle(phase gotcc.Phase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, &ErrorResp{Message: "method not allowed"})
+			return
+		}
+
+		if version, err := strconv.Atoi(r.Header.Get(HeaderProtocolVersion)); err == nil && version > ProtocolVersion {
+			writeJSON(w, http.StatusBadRequest, &ErrorResp{Message: fmt.Sprintf("unsupported protocol version: %d", version)})
+			return
+		}
+		codec, err := codecOf(r.Header)
+		if err != nil {
+			writeJSON(w, http.StatusUnsupportedMediaType, &ErrorResp{Message: err.Error()})
+			return
+		}
+
+		// 同一个幂等键的请求，直接复用此前的处理结果
+		key := r.Header.Get(HeaderIdempotencyKey)
+		status, body := h.cache.do(key, func() (int, interface{}) {
+			return h.serve(r, codec, phase)
+		})
+		write(w, codec, status, body)
+	}
+}
+
+func (h *Handler) serve(r *http.Request, codec gotcc.Codec, phase gotcc.Phase) (int, interface{}) {
+	// 调用方透传的超时时长与本地指定的超时时长，以较早的为准
+	ctx := r.Context()
+	if ms, err := strconv.ParseInt(r.Header.Get(HeaderTimeout), 10, 64); err == nil && ms > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+		defer cancel()
+	}
+	ctx, cancel := withTimeout(ctx, h.opts.Timeout)
+	defer cancel()
+	if h.opts.Tracer != nil {
+		ctx = h.opts.Tracer.Extract(ctx, extractTrace(r.Header))
+	}
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusBadRequest, &ErrorResp{Message: err.Error()}
+	}
+
+	var resp *gotcc.TCCResp
+	switch phase {
+	case gotcc.PhaseTry:
+		var req gotcc.TCCReq
+		if err = codec.Unmarshal(reqBody, &req); err != nil {
+			return http.StatusBadRequest, &ErrorResp{Message: err.Error()}
+		}
+		if h.opts.Tracer != nil && len(req.Metadata) > 0 {
+			ctx = h.opts.Tracer.Extract(ctx, req.Metadata)
+		}
+		resp, err = h.component.Try(ctx, &req)
+	default:
+		var req PhaseTwoReq
+		if err = codec.Unmarshal(reqBody, &req); err != nil {
+			return http.StatusBadRequest, &ErrorResp{Message: err.Error()}
+		}
+		if phase == gotcc.PhaseConfirm {
+			resp, err = h.component.Confirm(ctx, req.TXID)
+		} else {
+			resp, err = h.component.Cancel(ctx, req.TXID)
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, &ErrorResp{Message: err.Error()}
+	}
+	if err != nil {
+		return http.StatusInternalServerError, &ErrorResp{Message: err.Error()}
+	}
+	if resp == nil {
+		return http.StatusInternalServerError, &ErrorResp{Message: "empty resp"}
+	}
+	return http.StatusOK, resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	write(w, gotcc.JSONCodec{}, status, body)
+}
+
+// 使用与请求相同的编解码方式写入响应
+func write(w http.ResponseWriter, codec gotcc.Codec, status int, body interface{}) {
+	respBody, err := codec.Marshal(body)
+	if err != nil {
+		codec, status = gotcc.JSONCodec{}, http.StatusInternalServerError
+		respBody, _ = json.Marshal(&ErrorResp{Message: err.Error()})
+	}
+	w.Header().Set("Content-Type", contentType(codec))
+	w.WriteHeader(status)
+	_, _ = w.Write(respBody)
+}
+
+// 基于幂等键缓存处理结果. 仅缓存明确的处理结果，5xx 异常不缓存，以便调用方重试
+type idempotencyCache struct {
+	ttl     time.Duration
+	mux     sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	done     chan struct{}
+	status   int
+	body     interface{}
+	expireAt time.Time
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:     ttl,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+func (c *idempotencyCache) do(key string, f func() (int, interface{})) (int, interface{}) {
+	if key == "" || c.ttl <= 0 {
+		return f()
+	}
+
+	c.mux.Lock()
+	now := time.Now()
+	// 顺带清理过期的缓存
+	for k, entry := range c.entries {
+		if !entry.expireAt.IsZero() && entry.expireAt.Before(now) {
+			delete(c.entries, k)
+		}
+	}
+	if entry, ok := c.entries[key]; ok {
+		c.mux.Unlock()
+		// 相同幂等键的请求正在处理中，等待其处理结果
+		<-entry.done
+		return entry.status, entry.body
+	}
+	entry := idempotencyEntry{done: make(chan struct{})}
+	c.entries[key] = &entry
+	c.mux.Unlock()
+
+	entry.status, entry.body = f()
+
+	c.mux.Lock()
+	if entry.status >= http.StatusInternalServerError {
+		delete(c.entries, key)
+	} else {
+		entry.expireAt = time.Now().Add(c.ttl)
+	}
+	c.mux.Unlock()
+	close(entry.done)
+	return entry.status, entry.body
+}
@@ -0,0 +1,261 @@
+package httptransport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/codec"
+)
+
+type mockComponent struct {
+	id       string
+	mutex    sync.Mutex
+	calls    map[gotcc.Phase]int
+	deadline time.Duration
+}
+
+func newMockComponent(id string) *mockComponent {
+	return &mockComponent{
+		id:    id,
+		calls: make(map[gotcc.Phase]int),
+	}
+}
+
+func (m *mockComponent) ID() string {
+	return m.id
+}
+
+func (m *mockComponent) Try(ctx context.Context, req *gotcc.TCCReq) (*gotcc.TCCResp, error) {
+	m.mutex.Lock()
+	m.calls[gotcc.PhaseTry]++
+	m.mutex.Unlock()
+
+	switch req.Data["flag"] {
+	case "reject":
+		return &gotcc.TCCResp{ComponentID: m.id, TXID: req.TXID}, nil
+	case "err":
+		return nil, errors.New("try err")
+	case "slow":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: req.TXID, ACK: true}, nil
+}
+
+func (m *mockComponent) Confirm(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls[gotcc.PhaseConfirm]++
+	if deadline, ok := ctx.Deadline(); ok {
+		m.deadline = time.Until(deadline)
+	}
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: txID, ACK: true}, nil
+}
+
+func (m *mockComponent) Cancel(ctx context.Context, txID string) (*gotcc.TCCResp, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls[gotcc.PhaseCancel]++
+	return &gotcc.TCCResp{ComponentID: m.id, TXID: txID, ACK: true}, nil
+}
+
+func (m *mockComponent) getCalls(phase gotcc.Phase) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.calls[phase]
+}
+
+func Test_Transport(t *testing.T) {
+	component := newMockComponent("a")
+	server := httptest.NewServer(NewHandler(component))
+	defer server.Close()
+
+	client := NewClient("a", server.URL+"/", WithClientTimeout(200*time.Millisecond))
+	assert.Equal(t, "a", client.ID())
+	assert.Equal(t, server.URL, client.BaseURL())
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		txID      string
+		flag      string
+		expectErr bool
+		ack       bool
+	}{
+		{name: "ack", txID: "1", ack: true},
+		{name: "reject", txID: "2", flag: "reject"},
+		{name: "err", txID: "3", flag: "err", expectErr: true},
+		{name: "timeout", txID: "4", flag: "slow", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Try(ctx, &gotcc.TCCReq{
+				ComponentID: "a",
+				TXID:        tt.txID,
+				Data:        map[string]interface{}{"flag": tt.flag},
+			})
+			assert.Equal(t, tt.expectErr, err != nil)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.ack, resp.ACK)
+			assert.Equal(t, tt.txID, resp.TXID)
+		})
+	}
+
+	resp, err := client.Confirm(ctx, "1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, resp.ACK)
+	resp, err = client.Cancel(ctx, "2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, resp.ACK)
+}
+
+func Test_Transport_deadline(t *testing.T) {
+	component := newMockComponent("a")
+	server := httptest.NewServer(NewHandler(component, WithIdempotencyTTL(0)))
+	defer server.Close()
+	client := NewClient("a", server.URL)
+
+	// 未指定超时时长时，客户端与服务端均沿用调用方 ctx 的 deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := client.Confirm(ctx, "1")
+	assert.Equal(t, nil, err)
+	component.mutex.Lock()
+	assert.Equal(t, true, component.deadline > defaultTimeout)
+	component.mutex.Unlock()
+
+	// 显式指定的超时时长作为上限
+	capped := NewClient("a", server.URL, WithClientTimeout(200*time.Millisecond))
+	_, err = capped.Confirm(ctx, "2")
+	assert.Equal(t, nil, err)
+	component.mutex.Lock()
+	assert.Equal(t, true, component.deadline > 0 && component.deadline <= 200*time.Millisecond)
+	component.mutex.Unlock()
+}
+
+func Test_Transport_idempotency(t *testing.T) {
+	component := newMockComponent("a")
+	server := httptest.NewServer(NewHandler(component))
+	defer server.Close()
+
+	client := NewClient("a", server.URL)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Confirm(ctx, "1")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, resp.ACK)
+	}
+	assert.Equal(t, 1, component.getCalls(gotcc.PhaseConfirm))
+
+	// 异常结果不缓存，重试时会再次请求组件
+	for i := 0; i < 2; i++ {
+		_, err := client.Try(ctx, &gotcc.TCCReq{TXID: "2", Data: map[string]interface{}{"flag": "err"}})
+		assert.Equal(t, true, err != nil)
+	}
+	assert.Equal(t, 2, component.getCalls(gotcc.PhaseTry))
+}
+
+func Test_Transport_bad_request(t *testing.T) {
+	server := httptest.NewServer(NewHandler(newMockComponent("a")))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+PathTry, "application/json", strings.NewReader("{"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + PathTry)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+type headerTracer struct{}
+
+func (headerTracer) Start(ctx context.Context, name string, opts ...gotcc.SpanOption) (context.Context, gotcc.Span) {
+	return ctx, nil
+}
+
+func (headerTracer) Inject(ctx context.Context, carrier map[string]string) {
+	carrier["traceparent"] = "trace"
+}
+
+func (headerTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return ctx
+}
+
+func Test_Transport_trace(t *testing.T) {
+	var carrier map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		carrier = extractTrace(r.Header)
+		writeJSON(w, http.StatusOK, &gotcc.TCCResp{ACK: true})
+	}))
+	defer server.Close()
+
+	client := NewClient("a", server.URL, WithClientTracer(headerTracer{}))
+	_, err := client.Cancel(context.Background(), "1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]string{"traceparent": "trace"}, carrier)
+}
+
+func Test_Transport_codec(t *testing.T) {
+	var contentType string
+	handler := NewHandler(newMockComponent("a"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient("a", server.URL, WithClientCodec(codec.MsgPack{}))
+	resp, err := client.Try(context.Background(), &gotcc.TCCReq{TXID: "1", Data: map[string]interface{}{"flag": "reject"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, resp.ACK)
+	assert.Equal(t, "1", resp.TXID)
+	assert.Equal(t, "application/vnd.gotcc+msgpack", contentType)
+
+	// 未注册的编解码方式以及更高版本的协议均会被拒绝
+	req, _ := http.NewRequest(http.MethodPost, server.URL+PathConfirm, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/vnd.gotcc+unknown")
+	httpResp, err := http.DefaultClient.Do(req)
+	assert.Equal(t, nil, err)
+	httpResp.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, httpResp.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+PathConfirm, strings.NewReader("{}"))
+	req.Header.Set(HeaderProtocolVersion, "2")
+	httpResp, err = http.DefaultClient.Do(req)
+	assert.Equal(t, nil, err)
+	httpResp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, httpResp.StatusCode)
+}
+
+func Test_Transport_reject_reason(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusConflict, &ErrorResp{Code: "out_of_stock", Message: "inventory not enough"})
+	}))
+	defer server.Close()
+
+	resp, err := NewClient("a", server.URL).Try(context.Background(), &gotcc.TCCReq{TXID: "1"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, resp.ACK)
+	assert.Equal(t, &gotcc.RejectReason{Code: "out_of_stock", Message: "inventory not enough"}, resp.Reason)
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 常用的日志字段 key
+const (
+	FieldTXID      = "txID"
+	FieldTraceID   = "traceID"
+	FieldRequestID = "requestID"
+)
+
+type fieldsCtxKey struct{}
+
+// 在 ctx 中追加日志字段，后续基于该 ctx 打印的日志都会自动携带
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	parent, _ := ctx.Value(fieldsCtxKey{}).([]Field)
+	merged := make([]Field, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsCtxKey{}, merged)
+}
+
+// 在 ctx 中记录事务 id
+func WithTXID(ctx context.Context, txID string) context.Context {
+	return WithFields(ctx, String(FieldTXID, txID))
+}
+
+// 在 ctx 中记录请求 id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithFields(ctx, String(FieldRequestID, requestID))
+}
+
+// 从 ctx 中提取日志字段的函数，例如从链路信息中提取 trace id
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	extractorsMux sync.RWMutex
+	extractors    []ContextExtractor
+)
+
+// 注册 ctx 日志字段提取函数
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractorsMux.Lock()
+	defer extractorsMux.Unlock()
+	extractors = append(extractors, extractor)
+}
+
+// 获取 ctx 中的全部日志字段，包括通过 WithFields 追加的字段，以及各提取函数得到的字段
+func FieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsCtxKey{}).([]Field)
+	fields = fields[:len(fields):len(fields)]
+
+	extractorsMux.RLock()
+	defer extractorsMux.RUnlock()
+	for _, extractor := range extractors {
+		fields = append(fields, extractor(ctx)...)
+	}
+	return fields
+}
+
+// 支持携带字段的 Logger 可选实现该接口，字段会以结构化的形式输出
+type FieldsLogger interface {
+	WithFields(fields ...Field) Logger
+}
+
+// 获取携带了 ctx 中日志字段的默认 Logger
+func contextLogger(ctx context.Context) Logger {
+	logger := GetDefaultLogger()
+	fields := FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	if fieldsLogger, ok := logger.(FieldsLogger); ok {
+		return fieldsLogger.WithFields(fields...)
+	}
+	return &suffixLogger{Logger: logger, suffix: formatFields(fields)}
+}
+
+// 把字段以 key=value 的形式追加到日志内容之后
+type suffixLogger struct {
+	Logger
+	suffix string
+}
+
+func (s *suffixLogger) Error(v ...interface{}) { s.Logger.Error(fmt.Sprint(v...) + s.suffix) }
+func (s *suffixLogger) Warn(v ...interface{})  { s.Logger.Warn(fmt.Sprint(v...) + s.suffix) }
+func (s *suffixLogger) Info(v ...interface{})  { s.Logger.Info(fmt.Sprint(v...) + s.suffix) }
+func (s *suffixLogger) Debug(v ...interface{}) { s.Logger.Debug(fmt.Sprint(v...) + s.suffix) }
+
+func (s *suffixLogger) Errorf(format string, v ...interface{}) {
+	s.Logger.Error(fmt.Sprintf(format, v...) + s.suffix)
+}
+
+func (s *suffixLogger) Warnf(format string, v ...interface{}) {
+	s.Logger.Warn(fmt.Sprintf(format, v...) + s.suffix)
+}
+
+func (s *suffixLogger) Infof(format string, v ...interface{}) {
+	s.Logger.Info(fmt.Sprintf(format, v...) + s.suffix)
+}
+
+func (s *suffixLogger) Debugf(format string, v ...interface{}) {
+	s.Logger.Debug(fmt.Sprintf(format, v...) + s.suffix)
+}
+
+func formatFields(fields []Field) string {
+	var b strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&b, " %s=%v", field.Key, field.Value)
+	}
+	return b.String()
+}
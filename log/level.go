@@ -0,0 +1,54 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// 可在运行时调整的日志级别. 本身即为一个 http.Handler，
+// GET 请求返回当前日志级别，PUT 请求以 {"level":"debug"} 的形式修改日志级别，可挂载到管理端口上
+type AtomicLevel struct {
+	level zap.AtomicLevel
+}
+
+func NewAtomicLevel(level string) *AtomicLevel {
+	a := AtomicLevel{level: zap.NewAtomicLevel()}
+	if err := a.SetLevel(level); err != nil {
+		a.level.SetLevel(Levels["info"])
+	}
+	return &a
+}
+
+// SetLevel 修改日志级别
+func (a *AtomicLevel) SetLevel(level string) error {
+	zapLevel, ok := Levels[level]
+	if !ok {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	a.level.SetLevel(zapLevel)
+	return nil
+}
+
+// Level 获取当前日志级别
+func (a *AtomicLevel) Level() string {
+	return a.level.Level().String()
+}
+
+func (a *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.level.ServeHTTP(w, r)
+}
+
+// 默认 Logger 使用的日志级别
+var defaultLevel = NewAtomicLevel("info")
+
+// DefaultLevel 获取默认 Logger 使用的日志级别，可用于挂载到管理端口上
+func DefaultLevel() *AtomicLevel {
+	return defaultLevel
+}
+
+// SetLevel 在运行时修改默认 Logger 的日志级别
+func SetLevel(level string) error {
+	return defaultLevel.SetLevel(level)
+}
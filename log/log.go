@@ -2,6 +2,11 @@ package log
 
 import (
 	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -20,13 +25,28 @@ type Logger interface {
 }
 
 var (
-	defaultLogger Logger
+	defaultLogger atomic.Value
+	defaultOnce   sync.Once
 )
 
-func init() {
-	defaultLogger = NewSugarLogger(NewOptions())
+// 用于在 atomic.Value 中存放不同实现类型的 Logger
+type loggerHolder struct {
+	Logger
 }
 
+// 日志输出位置
+const (
+	OutputFile   = "file"
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// 日志编码格式
+const (
+	EncodingConsole = "console"
+	EncodingJSON    = "json"
+)
+
 // Options 选项配置
 type Options struct {
 	LogName    string // 日志名称
@@ -36,6 +56,21 @@ type Options struct {
 	MaxSize    int    // 日志保留大小，以 M 为单位
 	MaxBackups int    // 保留文件个数
 	Compress   bool   // 是否压缩
+	Output     string // 输出位置，可选 file、stdout、stderr，默认为 stderr. 输出到滚动的日志文件需要显式开启，无法识别的取值同样输出到 stderr
+	Encoding   string // 编码格式，可选 console、json
+	// 运行时可调整的日志级别，设置后 LogLevel 不再生效
+	Level *AtomicLevel
+	// 自定义输出，设置后 Output 不再生效
+	Writer io.Writer
+	// 日志采样配置，为空时不采样
+	Sampling *SamplingOptions
+}
+
+// SamplingOptions 日志采样配置. 每个 Tick 周期内，相同级别和内容的日志只输出前 First 条，之后每 Thereafter 条输出一条
+type SamplingOptions struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
 }
 
 // Option 选项方法
@@ -52,6 +87,8 @@ func NewOptions(opts ...Option) Options {
 		MaxSize:    100,
 		MaxBackups: 3,
 		Compress:   true,
+		Output:     OutputStderr,
+		Encoding:   EncodingConsole,
 	}
 	for _, opt := range opts {
 		opt(&options)
@@ -66,10 +103,50 @@ func WithLogLevel(level string) Option {
 	}
 }
 
-// WithFileName 日志文件
+// WithFileName 输出到滚动的日志文件
 func WithFileName(filename string) Option {
 	return func(o *Options) {
 		o.FileName = filename
+		o.Output = OutputFile
+	}
+}
+
+// WithOutput 日志输出位置
+func WithOutput(output string) Option {
+	return func(o *Options) {
+		o.Output = output
+	}
+}
+
+// WithWriter 自定义日志输出
+func WithWriter(writer io.Writer) Option {
+	return func(o *Options) {
+		o.Writer = writer
+	}
+}
+
+// WithEncoding 日志编码格式
+func WithEncoding(encoding string) Option {
+	return func(o *Options) {
+		o.Encoding = encoding
+	}
+}
+
+// WithAtomicLevel 运行时可调整的日志级别
+func WithAtomicLevel(level *AtomicLevel) Option {
+	return func(o *Options) {
+		o.Level = level
+	}
+}
+
+// WithSampling 日志采样，用于抑制循环中反复出现的相同日志
+func WithSampling(tick time.Duration, first, thereafter int) Option {
+	return func(o *Options) {
+		o.Sampling = &SamplingOptions{
+			Tick:       tick,
+			First:      first,
+			Thereafter: thereafter,
+		}
 	}
 }
 
@@ -92,22 +169,57 @@ func NewSugarLogger(options Options) *zapLoggerWrapper {
 	w := &zapLoggerWrapper{options: options}
 	encoder := w.getEncoder()
 	writeSyncer := w.getLogWriter()
-	core := zapcore.NewCore(encoder, writeSyncer, Levels[options.LogLevel])
+	var level zapcore.LevelEnabler = Levels[options.LogLevel]
+	if options.Level != nil {
+		level = options.Level.level
+	}
+	core := zapcore.NewCore(encoder, writeSyncer, level)
+	if sampling := options.Sampling; sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, sampling.Tick, sampling.First, sampling.Thereafter)
+	}
 	w.SugaredLogger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)).Sugar()
 	return w
 }
 
+// WithFields 返回携带指定字段的 Logger，字段以结构化的形式输出
+func (w *zapLoggerWrapper) WithFields(fields ...Field) Logger {
+	args := make([]interface{}, 0, len(fields)<<1)
+	for _, field := range fields {
+		args = append(args, field.Key, field.Value)
+	}
+	return &zapLoggerWrapper{
+		SugaredLogger: w.SugaredLogger.With(args...),
+		options:       w.options,
+	}
+}
+
 func (w *zapLoggerWrapper) getEncoder() zapcore.Encoder {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
 	// 在日志文件中使用大写字母记录日志级别
 	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	if w.options.Encoding == EncodingJSON {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
 	// NewConsoleEncoder 打印更符合人们观察的方式
 	return zapcore.NewConsoleEncoder(encoderConfig)
 }
 
 func (w *zapLoggerWrapper) getLogWriter() zapcore.WriteSyncer {
+	if w.options.Writer != nil {
+		return zapcore.AddSync(w.options.Writer)
+	}
+
+	switch w.options.Output {
+	case OutputStdout:
+		return zapcore.Lock(os.Stdout)
+	case OutputFile:
+	default:
+		// 仅在显式指定 file 时写入日志文件，其余取值均输出到 stderr
+		return zapcore.Lock(os.Stderr)
+	}
+
 	return zapcore.AddSync(&lumberjack.Logger{
 		Filename:   w.options.FileName,
 		MaxAge:     w.options.MaxAge,
@@ -117,9 +229,20 @@ func (w *zapLoggerWrapper) getLogWriter() zapcore.WriteSyncer {
 	})
 }
 
-// GetDefaultLogger 获取默认日志实现
+// GetDefaultLogger 获取默认日志实现. 未通过 SetDefaultLogger 指定时，首次使用才会构造默认的 stderr 日志
 func GetDefaultLogger() Logger {
-	return defaultLogger
+	if holder, ok := defaultLogger.Load().(loggerHolder); ok {
+		return holder.Logger
+	}
+	defaultOnce.Do(func() {
+		defaultLogger.CompareAndSwap(nil, loggerHolder{Logger: NewSugarLogger(NewOptions(WithAtomicLevel(defaultLevel)))})
+	})
+	return defaultLogger.Load().(loggerHolder).Logger
+}
+
+// SetDefaultLogger 替换默认日志实现
+func SetDefaultLogger(logger Logger) {
+	defaultLogger.Store(loggerHolder{Logger: logger})
 }
 
 // Debugf 打印 Debug 日志
@@ -144,41 +267,42 @@ func Errorf(format string, args ...interface{}) {
 
 // DebugContext 打印 Debug 日志
 func DebugContext(ctx context.Context, args ...interface{}) {
-	GetDefaultLogger().Debug(args...)
+	contextLogger(ctx).Debug(args...)
 }
 
 // DebugContextf 打印 Debug 日志
 func DebugContextf(ctx context.Context, format string, args ...interface{}) {
-	GetDefaultLogger().Debugf(format, args...)
+	contextLogger(ctx).Debugf(format, args...)
 }
 
 // InfoContext 打印 Info 日志
 func InfoContext(ctx context.Context, args ...interface{}) {
-	GetDefaultLogger().Info(args...)
+	contextLogger(ctx).Info(args...)
 }
 
 // InfoContextf 打印 Info 日志
 func InfoContextf(ctx context.Context, format string, args ...interface{}) {
-	GetDefaultLogger().Infof(format, args...)
+	contextLogger(ctx).Infof(format, args...)
 }
 
 // WarnContext 打印 Warn 日志
 func WarnContext(ctx context.Context, args ...interface{}) {
-	GetDefaultLogger().Warn(args...)
+	contextLogger(ctx).Warn(args...)
 }
 
 // WarnContextf 打印 Warn 日志
 func WarnContextf(ctx context.Context, format string, args ...interface{}) {
-	GetDefaultLogger().Warnf(format, args...)
+	contextLogger(ctx).Warnf(format, args...)
 }
 
 // ErrorContext 打印 Error 日志
 func ErrorContext(ctx context.Context, args ...interface{}) {
-	GetDefaultLogger().Error(args...)
+	contextLogger(ctx).Error(args...)
 }
 
+// ErrorContextf 打印 Error 日志
 func ErrorContextf(ctx context.Context, format string, args ...interface{}) {
-	GetDefaultLogger().Errorf(format, args...)
+	contextLogger(ctx).Errorf(format, args...)
 }
 
 func Fatalf(format string, args ...interface{}) {
@@ -1,17 +1,43 @@
 package log
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_customer_logger(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "gotcc.log")
 	logger := NewSugarLogger(NewOptions(
-		WithFileName("gotcc.log"),
+		WithFileName(filename),
 		WithLogLevel("info"),
 	))
 	logger.Info("test customer logger running...")
+	_, err := os.Stat(filename)
+	assert.Equal(t, nil, err)
+}
+
+func Test_unknown_output(t *testing.T) {
+	// 未显式指定 file 的输出位置，包括空值与拼写错误，均输出到 stderr 而不会写入日志文件
+	for _, output := range []string{"", "stdrr"} {
+		filename := filepath.Join(t.TempDir(), "gotcc.log")
+		logger := NewSugarLogger(NewOptions(WithFileName(filename), WithOutput(output)))
+		logger.Info("test unknown output running...")
+		_, err := os.Stat(filename)
+		assert.Equal(t, true, errors.Is(err, os.ErrNotExist))
+	}
 }
 
 func Test_default_logger(t *testing.T) {
@@ -32,3 +58,131 @@ func Test_default_logger(t *testing.T) {
 	ErrorContext(ctx, "error...")
 	ErrorContextf(ctx, "error... now: %v", now)
 }
+
+func Test_slog_logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	logger.Log(context.Background(), LevelDebug, "ignored")
+	logger.Log(context.Background(), LevelError, "failed", String("txID", "1"), Err(errors.New("boom")))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "failed", entry["msg"])
+	assert.Equal(t, "1", entry["txID"])
+	assert.Equal(t, "boom", entry["error"])
+}
+
+func Test_structured_logger(t *testing.T) {
+	logger := NewStructuredLogger(nil)
+	logger.Log(context.Background(), LevelInfo, "structured logger running...", String("txID", "1"))
+}
+
+type recordLogger struct {
+	lines []string
+}
+
+func (r *recordLogger) Error(v ...interface{}) { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Warn(v ...interface{})  { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Info(v ...interface{})  { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Debug(v ...interface{}) { r.lines = append(r.lines, fmt.Sprint(v...)) }
+func (r *recordLogger) Errorf(format string, v ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, v...))
+}
+func (r *recordLogger) Warnf(format string, v ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, v...))
+}
+func (r *recordLogger) Infof(format string, v ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, v...))
+}
+func (r *recordLogger) Debugf(format string, v ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, v...))
+}
+
+type traceIDCtxKey struct{}
+
+func Test_context_fields(t *testing.T) {
+	origin := GetDefaultLogger()
+	defer SetDefaultLogger(origin)
+
+	logger := &recordLogger{}
+	SetDefaultLogger(logger)
+	assert.Equal(t, Logger(logger), GetDefaultLogger())
+
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		if traceID, _ := ctx.Value(traceIDCtxKey{}).(string); traceID != "" {
+			return []Field{String(FieldTraceID, traceID)}
+		}
+		return nil
+	})
+
+	ctx := WithTXID(context.Background(), "1")
+	ctx = WithRequestID(ctx, "req")
+	ctx = context.WithValue(ctx, traceIDCtxKey{}, "trace")
+	assert.Equal(t, []Field{String(FieldTXID, "1"), String(FieldRequestID, "req"), String(FieldTraceID, "trace")}, FieldsFromContext(ctx))
+
+	InfoContextf(ctx, "info %d", 1)
+	ErrorContext(ctx, "error")
+	Infof("no ctx")
+	NewStructuredLogger(nil).Log(ctx, LevelWarn, "structured", String("k", "v"))
+	assert.Equal(t, []string{
+		"info 1 txID=1 requestID=req traceID=trace",
+		"error txID=1 requestID=req traceID=trace",
+		"no ctx",
+		"structured txID=1 requestID=req traceID=trace k=v",
+	}, logger.lines)
+}
+
+func Test_zap_logger_with_fields(t *testing.T) {
+	logger := NewSugarLogger(NewOptions(WithOutput(OutputStdout)))
+	logger.WithFields(String(FieldTXID, "1")).Info("zap logger with fields running...")
+}
+
+func Test_atomic_level(t *testing.T) {
+	var buf bytes.Buffer
+	level := NewAtomicLevel("info")
+	logger := NewSugarLogger(NewOptions(WithWriter(&buf), WithEncoding(EncodingJSON), WithAtomicLevel(level)))
+
+	logger.Debug("debug before")
+	assert.Equal(t, 0, buf.Len())
+
+	assert.Equal(t, true, level.SetLevel("nope") != nil)
+	assert.Equal(t, nil, level.SetLevel("debug"))
+	assert.Equal(t, "debug", level.Level())
+	logger.Debug("debug after")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, "DEBUG", entry["level"])
+	assert.Equal(t, "debug after", entry["msg"])
+
+	// 通过 http 接口调整日志级别
+	recorder := httptest.NewRecorder()
+	level.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"error"}`)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "error", level.Level())
+
+	assert.Equal(t, nil, SetLevel("warn"))
+	assert.Equal(t, "warn", DefaultLevel().Level())
+	assert.Equal(t, nil, SetLevel("info"))
+}
+
+func Test_sampling_logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSugarLogger(NewOptions(WithWriter(&buf), WithEncoding(EncodingJSON), WithSampling(time.Minute, 2, 0)))
+	for i := 0; i < 10; i++ {
+		logger.Error("confirm failed")
+	}
+	assert.Equal(t, 2, strings.Count(buf.String(), "confirm failed"))
+}
+
+func Test_stdout_logger(t *testing.T) {
+	logger := NewSugarLogger(NewOptions(WithOutput(OutputStdout), WithEncoding(EncodingJSON)))
+	logger.Info("stdout logger running...")
+}
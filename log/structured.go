@@ -0,0 +1,120 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// 日志级别
+type Level int8
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// 结构化日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// 结构化日志. 实现方需要通过 FieldsFromContext 输出 ctx 中携带的日志字段
+type StructuredLogger interface {
+	Log(ctx context.Context, level Level, msg string, fields ...Field)
+}
+
+// 基于 log/slog 实现的结构化日志
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// logger 为空时使用 slog.Default()
+func NewSlogLogger(logger *slog.Logger) StructuredLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+func (s *slogLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	fields = append(FieldsFromContext(ctx), fields...)
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		if err, ok := field.Value.(error); ok {
+			attrs = append(attrs, slog.String(field.Key, err.Error()))
+			continue
+		}
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	s.logger.LogAttrs(ctx, slogLevels[level], msg, attrs...)
+}
+
+var slogLevels = map[Level]slog.Level{
+	LevelDebug: slog.LevelDebug,
+	LevelInfo:  slog.LevelInfo,
+	LevelWarn:  slog.LevelWarn,
+	LevelError: slog.LevelError,
+}
+
+// 基于 Logger 实现的结构化日志. Logger 实现了 FieldsLogger 时字段以结构化的形式输出，否则以 key=value 的形式追加到日志内容之后
+type structuredLogger struct {
+	logger Logger
+}
+
+// logger 为空时，每次打印日志都使用当时的默认 Logger
+func NewStructuredLogger(logger Logger) StructuredLogger {
+	return &structuredLogger{logger: logger}
+}
+
+func (s *structuredLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	logger := s.logger
+	if logger == nil {
+		logger = GetDefaultLogger()
+	}
+
+	fields = append(FieldsFromContext(ctx), fields...)
+	if fieldsLogger, ok := logger.(FieldsLogger); ok && len(fields) > 0 {
+		logger = fieldsLogger.WithFields(fields...)
+	} else {
+		msg += formatFields(fields)
+	}
+
+	switch level {
+	case LevelDebug:
+		logger.Debug(msg)
+	case LevelInfo:
+		logger.Info(msg)
+	case LevelWarn:
+		logger.Warn(msg)
+	default:
+		logger.Error(msg)
+	}
+}
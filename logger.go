@@ -0,0 +1,24 @@
+package gotcc
+
+import (
+	"context"
+
+	"github.com/xiaoxuxiansheng/gotcc/log"
+)
+
+// 日志字段 key
+const (
+	LogFieldTXID        = log.FieldTXID
+	LogFieldComponentID = "componentID"
+	LogFieldPhase       = "phase"
+)
+
+// 在 ctx 中追加组件维度的日志字段
+func withComponentLogFields(ctx context.Context, componentID string, phase Phase) context.Context {
+	return log.WithFields(ctx, log.String(LogFieldComponentID, componentID), log.String(LogFieldPhase, phase.String()))
+}
+
+// 打印日志，ctx 中记录的事务维度字段由 Logger 自动带上
+func (t *TXManager) log(ctx context.Context, level log.Level, msg string, fields ...log.Field) {
+	t.opts.Logger.Log(ctx, level, msg, fields...)
+}
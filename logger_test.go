@@ -0,0 +1,73 @@
+package gotcc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xiaoxuxiansheng/gotcc/log"
+)
+
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) entries() []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(s.buf.Bytes()))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func Test_txmanager_logger(t *testing.T) {
+	var buf syncBuffer
+	logger := log.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	txmanager := NewTXManager(newMockTXStore(), WithLogger(logger))
+	defer txmanager.Stop()
+
+	if err := txmanager.Register(newMockComponent("a")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	result, err := txmanager.Transaction(context.Background(), []*RequestEntity{{
+		ComponentID: "a",
+		Request: map[string]interface{}{
+			"reject_flag": true,
+		},
+	}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, false, result.Success)
+
+	entries := buf.entries()
+	if !assert.Equal(t, true, len(entries) > 0) {
+		return
+	}
+	assert.Equal(t, "tx try failed", entries[0]["msg"])
+	assert.Equal(t, result.TXID, entries[0][LogFieldTXID])
+	assert.Equal(t, "a", entries[0][LogFieldComponentID])
+	assert.Equal(t, PhaseTry.String(), entries[0][LogFieldPhase])
+}
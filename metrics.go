@@ -0,0 +1,32 @@
+package gotcc
+
+import "time"
+
+// 事务引擎的监控指标上报模块
+type Metrics interface {
+	// 开启了一笔事务
+	TXStarted()
+	// 一笔事务推进到终态，cost 为事务从创建到终态的耗时
+	TXFinished(status TXStatus, cost time.Duration)
+	// 对组件执行了一次 try/confirm/cancel 请求
+	ComponentCalled(componentID string, phase Phase, ack bool, err error, cost time.Duration)
+	// 轮询任务获取到的 hanging 状态事务数量
+	HangingTXs(cnt int)
+	// 轮询任务单次执行完成
+	MonitorTicked(cost time.Duration, err error)
+	// 轮询任务获取分布式锁失败
+	LockFailed(err error)
+	// 轮询任务当前的退避等级，0 代表未退避
+	BackOffLevel(level int)
+}
+
+// 默认的空实现，不上报任何指标
+type nopMetrics struct{}
+
+func (nopMetrics) TXStarted()                                                {}
+func (nopMetrics) TXFinished(TXStatus, time.Duration)                        {}
+func (nopMetrics) ComponentCalled(string, Phase, bool, error, time.Duration) {}
+func (nopMetrics) HangingTXs(int)                                            {}
+func (nopMetrics) MonitorTicked(time.Duration, error)                        {}
+func (nopMetrics) LockFailed(error)                                          {}
+func (nopMetrics) BackOffLevel(int)                                          {}
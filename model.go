@@ -9,6 +9,14 @@ type RequestEntity struct {
 	ComponentID string `json:"componentName"`
 	// 组件入参
 	Request map[string]interface{} `json:"request"`
+	// 本次 try 请求的超时时长，未指定时使用组件注册时的超时时长
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// 执行阶段. 阶段小的组件先执行 try，同一阶段内的组件并发执行，前序阶段失败时后续阶段不再执行
+	Stage int `json:"stage,omitempty"`
+	// 依赖的组件 id. 依赖的组件 try 成功之后才会执行当前组件的 try，互不依赖的组件并发执行
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// 基于依赖组件 try 的响应结果构造当前组件的请求参数，可选. deps 为依赖组件 id 到其响应结果的映射
+	BuildRequest func(request map[string]interface{}, deps map[string]*TCCResp) (map[string]interface{}, error) `json:"-"`
 }
 
 type ComponentEntities []*ComponentEntity
@@ -24,6 +32,76 @@ func (c ComponentEntities) ToComponents() []TCCComponent {
 type ComponentEntity struct {
 	Request   map[string]interface{}
 	Component TCCComponent
+	// try 请求的超时时长
+	Timeout time.Duration
+	// 执行阶段
+	Stage int
+	// 依赖的组件 id
+	DependsOn []string
+	// 基于依赖组件 try 的响应结果构造请求参数
+	BuildRequest func(request map[string]interface{}, deps map[string]*TCCResp) (map[string]interface{}, error)
+	// 是否为 saga 分支
+	Saga bool
+}
+
+// 是否有组件指定了需要随事务记录持久化的执行参数
+func (c ComponentEntities) persistent() bool {
+	for _, entity := range c {
+		if entity.Stage != 0 || len(entity.DependsOn) > 0 || entity.BuildRequest != nil || entity.Saga {
+			return true
+		}
+	}
+	return false
+}
+
+// 各组件之间的依赖关系
+func (c ComponentEntities) graph() (*branchGraph, error) {
+	branches := make([]branch, 0, len(c))
+	for _, entity := range c {
+		branches = append(branches, branch{
+			id:        entity.Component.ID(),
+			stage:     entity.Stage,
+			dependsOn: entity.DependsOn,
+			saga:      entity.Saga,
+		})
+	}
+	return newBranchGraph(branches)
+}
+
+// 按照依赖关系执行全部 try 请求所需的最长时长，即关键路径上各组件 try 请求超时时长之和.
+// 依赖关系非法时以全部组件超时时长之和兜底
+func (c ComponentEntities) criticalTimeout() time.Duration {
+	timeouts := make(map[string]time.Duration, len(c))
+	var sum time.Duration
+	for _, entity := range c {
+		timeouts[entity.Component.ID()] = entity.Timeout
+		sum += entity.Timeout
+	}
+	graph, err := c.graph()
+	if err != nil {
+		return sum
+	}
+	ordered, err := graph.order()
+	if err != nil {
+		return sum
+	}
+
+	// 拓扑序保证依赖的组件先于当前组件计算
+	finished := make(map[string]time.Duration, len(c))
+	var timeout time.Duration
+	for _, id := range ordered {
+		var start time.Duration
+		for _, dep := range graph.deps[id] {
+			if finished[dep] > start {
+				start = finished[dep]
+			}
+		}
+		finished[id] = start + timeouts[id]
+		if finished[id] > timeout {
+			timeout = finished[id]
+		}
+	}
+	return timeout
 }
 
 // 事务状态
@@ -36,12 +114,21 @@ const (
 	TXSuccessful TXStatus = "successful"
 	// 事务失败
 	TXFailure TXStatus = "failure"
+	// 事务 try 全部成功，等待人工审批
+	TXAwaitingApproval TXStatus = "awaiting-approval"
+	// 事务的决议已经记录，第二阶段尚未执行完成
+	TXDeciding TXStatus = "deciding"
 )
 
 func (t TXStatus) String() string {
 	return string(t)
 }
 
+// 事务是否已经结束，即第二阶段已经执行完成
+func (t TXStatus) finished() bool {
+	return t == TXSuccessful || t == TXFailure
+}
+
 type ComponentTryStatus string
 
 func (c ComponentTryStatus) String() string {
@@ -56,9 +143,42 @@ const (
 	TryFailure ComponentTryStatus = "failure"
 )
 
+// tcc 组件的执行阶段
+type Phase string
+
+func (p Phase) String() string {
+	return string(p)
+}
+
+const (
+	// 第一阶段 try 操作
+	PhaseTry Phase = "try"
+	// 第二阶段 confirm 操作
+	PhaseConfirm Phase = "confirm"
+	// 第二阶段 cancel 操作
+	PhaseCancel Phase = "cancel"
+)
+
 type ComponentTryEntity struct {
 	ComponentID string
 	TryStatus   ComponentTryStatus
+	// 组件的请求参数，仅在 txStore 实现了 TXDetailStore 时持久化
+	Request *Payload `json:"request,omitempty"`
+	// 执行阶段，仅在 txStore 实现了 TXDetailStore 时持久化
+	Stage int `json:"stage,omitempty"`
+	// 依赖的组件 id，仅在 txStore 实现了 TXDetailStore 时持久化
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// 是否为 saga 分支，仅在 txStore 实现了 TXDetailStore 时持久化
+	Saga bool `json:"saga,omitempty"`
+	// try 请求的超时时长，仅在 txStore 实现了 TXDetailStore 时持久化
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// 请求参数是否基于依赖组件的响应结果构造，仅在 txStore 实现了 TXDetailStore 时持久化.
+	// 构造方法无法持久化，续跑该组件时需要由开启事务的节点提供
+	BuildRequest bool `json:"buildRequest,omitempty"`
+	// try 请求的执行结果，仅在 txStore 实现了 TXResultStore 时持久化
+	Result *Payload `json:"result,omitempty"`
+	// try 请求的拒绝原因，仅在 txStore 实现了 TXResultStore 时持久化
+	Reason *RejectReason `json:"reason,omitempty"`
 }
 
 // 事务
@@ -67,9 +187,100 @@ type Transaction struct {
 	Components []*ComponentTryEntity
 	Status     TXStatus  `json:"status"`
 	CreatedAt  time.Time `json:"createdAt"`
+	// 事务元数据，例如开启事务时的链路追踪信息
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// 事务的截止时间，超过该时间仍有组件 try 请求处于 hanging 状态时，事务置为失败.
+	// 未持久化截止时间时，以 CreatedAt 加上 TXManager 的 Timeout 作为截止时间
+	Deadline time.Time `json:"deadline,omitempty"`
+	// 事务的标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// 幂等键
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	// 第二阶段请求的重试策略
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// 事务的优先级
+	Priority int `json:"priority,omitempty"`
+	// 是否为暂挂事务. 暂挂事务 try 全部成功后等待调用方决议，不会被自动 confirm
+	Hold bool `json:"hold,omitempty"`
+	// 审批时长. 大于 0 时事务 try 全部成功后进入待审批状态，审批通过后才会 confirm
+	ApprovalTimeout time.Duration `json:"approvalTimeout,omitempty"`
+	// 审批截止时间，事务进入待审批状态时设置，超过该时间仍未审批时事务自动 cancel
+	ApprovalDeadline time.Time `json:"approvalDeadline,omitempty"`
+	// 已记录的决议结果，successful 为 confirm，failure 为 cancel. 仅在事务处于 deciding 状态时有效
+	Decision TXStatus `json:"decision,omitempty"`
+	// 定时 confirm 的时间. try 全部成功后等到该时间才会 confirm，由轮询任务推进
+	ConfirmAt time.Time `json:"confirmAt,omitempty"`
+	// 暂挂的过期时间. 超过该时间仍未决议的暂挂事务自动 cancel
+	HoldExpireAt time.Time `json:"holdExpireAt,omitempty"`
 }
 
-func (t *Transaction) getStatus(createdBefore time.Time) TXStatus {
+// 获取指定组件的 try 记录，组件不存在时返回 nil
+func (t *Transaction) component(componentID string) *ComponentTryEntity {
+	for _, component := range t.Components {
+		if component.ComponentID == componentID {
+			return component
+		}
+	}
+	return nil
+}
+
+// 事务日志中记录的各组件之间的依赖关系
+func (t *Transaction) graph() (*branchGraph, error) {
+	branches := make([]branch, 0, len(t.Components))
+	for _, component := range t.Components {
+		branches = append(branches, branch{
+			id:        component.ComponentID,
+			stage:     component.Stage,
+			dependsOn: component.DependsOn,
+			saga:      component.Saga,
+		})
+	}
+	return newBranchGraph(branches)
+}
+
+// 按照依赖关系排序的组件，reverse 为 true 时逆序. 依赖关系非法时保持原有顺序
+func (t *Transaction) orderedComponents(reverse bool) []*ComponentTryEntity {
+	idToComponent := make(map[string]*ComponentTryEntity, len(t.Components))
+	for _, component := range t.Components {
+		idToComponent[component.ComponentID] = component
+	}
+
+	components := make([]*ComponentTryEntity, 0, len(t.Components))
+	var ordered []string
+	if g, err := t.graph(); err == nil {
+		ordered, _ = g.order()
+	}
+	if ordered == nil {
+		components = append(components, t.Components...)
+	}
+	for _, id := range ordered {
+		components = append(components, idToComponent[id])
+	}
+
+	if reverse {
+		for i, j := 0, len(components)-1; i < j; i, j = i+1, j-1 {
+			components[i], components[j] = components[j], components[i]
+		}
+	}
+	return components
+}
+
+// 事务 try 阶段的截止时间. 未持久化截止时间时，以 CreatedAt 加上 TXManager 的 Timeout 兜底
+func (t *Transaction) deadline(timeout time.Duration) time.Time {
+	if t.Deadline.IsZero() {
+		return t.CreatedAt.Add(timeout)
+	}
+	return t.Deadline
+}
+
+func (t *Transaction) getStatus(now time.Time, timeout time.Duration) TXStatus {
+	// 0 已经记录决议的，以决议结果为准，不再根据时间推断
+	if t.Status == TXDeciding {
+		return t.Decision
+	}
+
+	deadline := t.deadline(timeout)
+
 	// 1 如果当中出现失败的，直接置为失败
 	var hangingExist bool
 	for _, component := range t.Components {
@@ -79,16 +290,26 @@ func (t *Transaction) getStatus(createdBefore time.Time) TXStatus {
 		hangingExist = hangingExist || (component.TryStatus != TrySucceesful)
 	}
 
-	// 2 如果存在 hanging 状态，并且已经超时，也直接置为失败
-	if hangingExist && t.CreatedAt.Before(createdBefore) {
+	// 2 如果存在 hanging 状态，并且已经超时，也直接置为失败. 包括截止时间之前仍未回调结果的异步 try
+	if hangingExist && deadline.Before(now) {
 		return TXFailure
 	}
 
-	// 3 如果存在组件 try 操作处于 hanging 状态，则返回 hanging 状态
+	// 3 如果存在组件 try 操作处于 hanging 状态，则返回 hanging 状态. 异步 try 在参与方回调之前同样处于 hanging 状态
 	if hangingExist {
 		return TXHanging
 	}
 
-	// 4 走到这个分支必然意味着所有组件的 try 操作都成功了
+	// 4 走到这个分支必然意味着所有组件的 try 操作都成功了. 需要审批的事务等待审批，超过审批截止时间时置为失败
+	if t.ApprovalTimeout > 0 {
+		if !t.ApprovalDeadline.IsZero() && t.ApprovalDeadline.Before(now) {
+			return TXFailure
+		}
+		return TXAwaitingApproval
+	}
+	// 暂挂超过过期时间仍未决议的，同样置为失败
+	if t.Hold && !t.HoldExpireAt.IsZero() && t.HoldExpireAt.Before(now) {
+		return TXFailure
+	}
 	return TXSuccessful
 }
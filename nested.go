@@ -0,0 +1,105 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+)
+
+// 子事务结果中记录子事务 id 的 key
+const SubTXResultTXID = "subTXID"
+
+// 基于父事务的 try 请求参数构造子事务的各组件请求
+type SubTXRequestBuilder func(req *TCCReq) ([]*RequestEntity, error)
+
+// 把 manager 上的一笔事务包装为 tcc 组件，作为另一个 TXManager 事务中的一个分支，即嵌套事务:
+// try 以暂挂的方式开启子事务并执行各子组件的 try，confirm/cancel 决议子事务，由子事务扇出到各子组件.
+// 子事务 id 由父事务 id 与组件 id 拼接而成，父事务的轮询任务重试 confirm/cancel 时可以找回对应的子事务.
+// 要求 manager 的 txStore 实现 TXDetailStore 与 TXDecisionStore
+func NewSubTransaction(id string, manager *TXManager, build SubTXRequestBuilder, opts ...TXOption) TCCComponent {
+	return &subTransaction{
+		id:      id,
+		manager: manager,
+		build:   build,
+		opts:    opts,
+	}
+}
+
+type subTransaction struct {
+	id      string
+	manager *TXManager
+	build   SubTXRequestBuilder
+	opts    []TXOption
+}
+
+func (s *subTransaction) ID() string {
+	return s.id
+}
+
+func (s *subTransaction) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	reqs, err := s.build(req)
+	if err != nil {
+		return nil, componentError(s.id, PhaseTry, err)
+	}
+
+	subTXID := s.subTXID(req.TXID)
+	opts := append(append(make([]TXOption, 0, len(s.opts)+2), s.opts...), WithTXID(subTXID), WithHold())
+	result, err := s.manager.Transaction(ctx, reqs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := TCCResp{
+		ComponentID: s.id,
+		TXID:        req.TXID,
+		ACK:         result.Success,
+		Result:      map[string]interface{}{SubTXResultTXID: subTXID},
+	}
+	if !result.Success {
+		resp.Reason = subTXReason(result)
+	}
+	return &resp, nil
+}
+
+func (s *subTransaction) Confirm(ctx context.Context, txID string) (*TCCResp, error) {
+	return s.resolve(ctx, txID, true)
+}
+
+func (s *subTransaction) Cancel(ctx context.Context, txID string) (*TCCResp, error) {
+	return s.resolve(ctx, txID, false)
+}
+
+// 决议子事务. 出错时交由父事务的轮询任务重试
+func (s *subTransaction) resolve(ctx context.Context, txID string, commit bool) (*TCCResp, error) {
+	if err := s.manager.ResolveTX(ctx, s.subTXID(txID), commit); err != nil {
+		return nil, err
+	}
+	return &TCCResp{
+		ComponentID: s.id,
+		TXID:        txID,
+		ACK:         true,
+	}, nil
+}
+
+func (s *subTransaction) subTXID(txID string) string {
+	return txID + "/" + s.id
+}
+
+// 优先以子事务中失败组件的拒绝原因作为子事务的拒绝原因，其次以 try 阶段的错误作为拒绝原因
+func subTXReason(result *TXResult) *RejectReason {
+	for _, component := range result.Components {
+		if component.TryStatus == TryFailure && component.Reason != nil {
+			return component.Reason
+		}
+	}
+	if result.Err == nil {
+		return nil
+	}
+	reason := RejectReason{
+		Code:    ErrTryFailed.Error(),
+		Message: result.Err.Error(),
+	}
+	if errors.Is(result.Err, ErrTryRejected) {
+		reason.Code = ErrTryRejected.Error()
+	}
+	return &reason
+}
@@ -0,0 +1,114 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_txManager_nested(t *testing.T) {
+	tests := []struct {
+		name        string
+		reject      bool
+		rejectChild bool
+		success     bool
+		calls       []string
+	}{
+		{
+			// 子组件在父事务的 try 全部成功、进入第二阶段后才会被 confirm
+			name:    "success",
+			success: true,
+			calls:   []string{"child:try", "parent:try", "child:confirm", "parent:confirm"},
+		},
+		{
+			// 父事务中其他组件失败时，子事务随之 cancel
+			name:   "reject",
+			reject: true,
+			calls:  []string{"child:try", "parent:try", "parent:cancel", "child:cancel"},
+		},
+		{
+			// 子组件失败时子事务直接 cancel，父事务的 cancel 不会重复调用子组件
+			name:        "reject child",
+			rejectChild: true,
+			calls:       []string{"child:try", "child:cancel", "parent:cancel"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r recorder
+			childManager := NewTXManager(newMockTXStore())
+			defer childManager.Stop()
+			assert.Equal(t, nil, childManager.Register(&recordComponent{TCCComponent: newMockComponent("child"), recorder: &r}))
+
+			parentManager := NewTXManager(newMockTXStore())
+			defer parentManager.Stop()
+			assert.Equal(t, nil, parentManager.Register(NewSubTransaction("sub", childManager, func(req *TCCReq) ([]*RequestEntity, error) {
+				return []*RequestEntity{{ComponentID: "child", Request: req.Data}}, nil
+			})))
+			assert.Equal(t, nil, parentManager.Register(&recordComponent{TCCComponent: newMockComponent("parent"), recorder: &r}))
+
+			ctx := context.Background()
+			result, err := parentManager.Transaction(ctx, []*RequestEntity{
+				{ComponentID: "sub", Request: map[string]interface{}{"reject_flag": tt.rejectChild}},
+				{ComponentID: "parent", Request: map[string]interface{}{"reject_flag": tt.reject}, DependsOn: []string{"sub"}},
+			})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.calls, r.calls)
+
+			subTXID := result.Component("sub").Result[SubTXResultTXID]
+			assert.Equal(t, result.TXID+"/sub", subTXID)
+			subTX, err := childManager.GetTX(ctx, subTXID.(string))
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, subTX.Hold)
+			status := TXFailure
+			if tt.success {
+				status = TXSuccessful
+			}
+			assert.Equal(t, status, subTX.Status)
+			if tt.rejectChild {
+				assert.NotEqual(t, nil, result.Component("sub").Reason)
+			}
+		})
+	}
+}
+
+func Test_txManager_ResolveTX(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore())
+	defer txManager.Stop()
+	var r recorder
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("a"), recorder: &r}))
+	ctx := context.Background()
+
+	// 暂挂的事务在 try 全部成功后保持 hanging，轮询任务不会推进
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithHold())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, TXHanging, result.Status)
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	txStatus, err := txManager.advanceProgress(ctx, tx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXHanging, txStatus)
+	assert.Equal(t, []string{"a:try"}, r.calls)
+
+	// 决议是幂等的，与已决议结果相反的决议报错
+	assert.Equal(t, nil, txManager.ResolveTX(ctx, result.TXID, true))
+	assert.Equal(t, nil, txManager.ResolveTX(ctx, result.TXID, true))
+	assert.Equal(t, []string{"a:try", "a:confirm"}, r.calls)
+	assert.Equal(t, true, errors.Is(txManager.ResolveTX(ctx, result.TXID, false), ErrStoreConflict))
+
+	// 取消不存在的事务视为成功，例如父事务在子事务开启前就已失败
+	assert.Equal(t, nil, txManager.ResolveTX(ctx, "unknown", false))
+	assert.Equal(t, true, errors.Is(txManager.ResolveTX(ctx, "unknown", true), ErrTXNotFound))
+
+	// 基础的 TXStore 不支持暂挂事务
+	baseManager := NewTXManager(&baseTXStore{TXStore: newMockTXStore()})
+	defer baseManager.Stop()
+	assert.Equal(t, nil, baseManager.Register(newMockComponent("a")))
+	_, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithHold())
+	assert.Equal(t, true, errors.Is(err, ErrStoreUnsupported))
+}
@@ -1,12 +1,28 @@
 package gotcc
 
-import "time"
+import (
+	"time"
+
+	"github.com/xiaoxuxiansheng/gotcc/log"
+)
 
 type Options struct {
-	// 事务执行时长限制
+	// 事务执行时长限制，同时作为组件未指定超时时长时，各阶段请求的默认超时时长
 	Timeout time.Duration
 	// 轮询监控任务间隔时长
 	MonitorTick time.Duration
+	// 监控指标上报模块
+	Metrics Metrics
+	// 链路追踪模块
+	Tracer Tracer
+	// 日志打印模块，默认使用 log 包的默认 Logger
+	Logger log.StructuredLogger
+	// 组件注册中心，用于获取本地未注册的组件
+	Registry Registry
+	// 基于组件访问地址构造 tcc 组件
+	Dialer Dialer
+	// 请求参数持久化到事务日志时使用的编解码方式，默认为 json
+	Codec Codec
 }
 
 type Option func(*Options)
@@ -31,6 +47,38 @@ func WithMonitorTick(tick time.Duration) Option {
 	}
 }
 
+func WithMetrics(metrics Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = metrics
+	}
+}
+
+func WithTracer(tracer Tracer) Option {
+	return func(o *Options) {
+		o.Tracer = tracer
+	}
+}
+
+func WithLogger(logger log.StructuredLogger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// 本地未注册的组件，通过 registry 查询其访问地址，再由 dialer 构造出对应的 tcc 组件
+func WithRegistry(registry Registry, dialer Dialer) Option {
+	return func(o *Options) {
+		o.Registry = registry
+		o.Dialer = dialer
+	}
+}
+
+func WithCodec(codec Codec) Option {
+	return func(o *Options) {
+		o.Codec = codec
+	}
+}
+
 func repair(o *Options) {
 	if o.MonitorTick <= 0 {
 		o.MonitorTick = 10 * time.Second
@@ -39,4 +87,20 @@ func repair(o *Options) {
 	if o.Timeout <= 0 {
 		o.Timeout = 5 * time.Second
 	}
+
+	if o.Metrics == nil {
+		o.Metrics = nopMetrics{}
+	}
+
+	if o.Tracer == nil {
+		o.Tracer = nopTracer{}
+	}
+
+	if o.Codec == nil {
+		o.Codec = JSONCodec{}
+	}
+
+	if o.Logger == nil {
+		o.Logger = log.NewStructuredLogger(nil)
+	}
 }
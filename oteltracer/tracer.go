@@ -0,0 +1,98 @@
+package oteltracer
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/log"
+)
+
+const instrumentationName = "github.com/xiaoxuxiansheng/gotcc"
+
+// 基于 opentelemetry 实现的 gotcc.Tracer
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// tracer 和 propagator 为空时，分别使用 otel 全局注册的 TracerProvider 和 TextMapPropagator
+func New(tracer trace.Tracer, propagator propagation.TextMapPropagator) *Tracer {
+	if tracer == nil {
+		tracer = otel.Tracer(instrumentationName)
+	}
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return &Tracer{
+		tracer:     tracer,
+		propagator: propagator,
+	}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, opts ...gotcc.SpanOption) (context.Context, gotcc.Span) {
+	conf := gotcc.NewSpanConfig(opts...)
+
+	startOpts := make([]trace.SpanStartOption, 0, 2)
+	if len(conf.Attributes) > 0 {
+		attrs := make([]attribute.KeyValue, 0, len(conf.Attributes))
+		for k, v := range conf.Attributes {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+		startOpts = append(startOpts, trace.WithAttributes(attrs...))
+	}
+
+	// 把 carrier 形式的关联链路还原为 span link
+	for _, carrier := range conf.Links {
+		spanCtx := trace.SpanContextFromContext(t.propagator.Extract(context.Background(), propagation.MapCarrier(carrier)))
+		if !spanCtx.IsValid() {
+			continue
+		}
+		startOpts = append(startOpts, trace.WithLinks(trace.Link{SpanContext: spanCtx}))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, startOpts...)
+	return ctx, &otelSpan{span: span}
+}
+
+func (t *Tracer) Inject(ctx context.Context, carrier map[string]string) {
+	t.propagator.Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+func (t *Tracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return t.propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (o *otelSpan) SetAttribute(key, value string) {
+	o.span.SetAttributes(attribute.String(key, value))
+}
+
+func (o *otelSpan) RecordError(err error) {
+	o.span.RecordError(err)
+	o.span.SetStatus(codes.Error, err.Error())
+}
+
+func (o *otelSpan) End() {
+	o.span.End()
+}
+
+// 从 ctx 中提取 trace id 作为日志字段，可通过 log.RegisterContextExtractor 注册到 log 包中
+func LogFields(ctx context.Context) []log.Field {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return nil
+	}
+	return []log.Field{
+		log.String(log.FieldTraceID, spanCtx.TraceID().String()),
+		log.String("spanID", spanCtx.SpanID().String()),
+	}
+}
@@ -0,0 +1,72 @@
+package oteltracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xiaoxuxiansheng/gotcc"
+	"github.com/xiaoxuxiansheng/gotcc/log"
+)
+
+func Test_Tracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := New(provider.Tracer("test"), propagation.TraceContext{})
+
+	// 原始链路
+	ctx, origin := tracer.Start(context.Background(), "origin")
+	carrier := make(map[string]string)
+	tracer.Inject(ctx, carrier)
+	origin.End()
+	assert.NotEmpty(t, carrier["traceparent"])
+
+	// 远端延续链路
+	remoteCtx := tracer.Extract(context.Background(), carrier)
+	_, child := tracer.Start(remoteCtx, "child", gotcc.WithSpanAttribute(gotcc.SpanAttrTXID, "tx"))
+	child.RecordError(errors.New("err"))
+	child.End()
+
+	// 通过 link 关联原始链路
+	_, linked := tracer.Start(context.Background(), "linked", gotcc.WithSpanLink(carrier))
+	linked.End()
+
+	spans := recorder.Ended()
+	if !assert.Equal(t, 3, len(spans)) {
+		return
+	}
+	originCtx := spans[0].SpanContext()
+	assert.Equal(t, originCtx.TraceID(), spans[1].SpanContext().TraceID())
+	assert.Equal(t, originCtx.SpanID(), spans[1].Parent().SpanID())
+	assert.Equal(t, []attribute.KeyValue{attribute.String(gotcc.SpanAttrTXID, "tx")}, spans[1].Attributes())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+
+	assert.NotEqual(t, originCtx.TraceID(), spans[2].SpanContext().TraceID())
+	if !assert.Equal(t, 1, len(spans[2].Links())) {
+		return
+	}
+	assert.Equal(t, originCtx.SpanID(), spans[2].Links()[0].SpanContext.SpanID())
+}
+
+func Test_LogFields(t *testing.T) {
+	assert.Equal(t, 0, len(LogFields(context.Background())))
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := New(provider.Tracer("test"), nil).Start(context.Background(), "span")
+	defer span.End()
+
+	fields := LogFields(ctx)
+	if !assert.Equal(t, 2, len(fields)) {
+		return
+	}
+	assert.Equal(t, log.FieldTraceID, fields[0].Key)
+	assert.Equal(t, trace.SpanContextFromContext(ctx).TraceID().String(), fields[0].Value)
+}
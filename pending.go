@@ -0,0 +1,206 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xiaoxuxiansheng/gotcc/log"
+)
+
+// 上报异步 try 的执行结果. 组件的 try 响应 Pending 时，参与方在得出结果后通过该方法回调.
+// 回调成功时续跑依赖于该组件的后续组件，随后推进事务；超过事务截止时间仍未回调时事务置为失败.
+// 回调可以由任意节点接收，开启事务的节点重启后同样适用.
+// 重复上报相同的结果视为成功，并重新续跑尚未得出结果的后续组件；上报与已记录结果相反的结果，或者事务已因超时等原因结束时报错.
+// 超过截止时间之后上报的成功结果不再记录，事务随之 cancel
+func (t *TXManager) ReportTry(ctx context.Context, txID, componentID string, ack bool) error {
+	tx, err := t.txStore.GetTX(ctx, txID)
+	if err != nil {
+		return err
+	}
+	component := tx.component(componentID)
+	if component == nil {
+		return componentError(componentID, PhaseTry, fmt.Errorf("%w: not in tx: %s", ErrComponentNotFound, txID))
+	}
+
+	tryStatus := TryFailure
+	if ack {
+		tryStatus = TrySucceesful
+	}
+	switch {
+	case component.TryStatus == tryStatus && tx.Status != TXHanging:
+		return nil
+	case component.TryStatus == tryStatus:
+		// 此前的回调可能未能执行完后续组件，继续推进
+	case component.TryStatus != TryHanging:
+		return componentError(componentID, PhaseTry, fmt.Errorf("%w: try is already %s", ErrStoreConflict, component.TryStatus))
+	case tx.Status == TXFailure && !ack:
+		return nil
+	case tx.Status != TXHanging:
+		return componentError(componentID, PhaseTry, fmt.Errorf("%w: tx: %s is already %s", ErrStoreConflict, txID, tx.Status))
+	case ack && tx.deadline(t.opts.Timeout).Before(time.Now()):
+		// 超过截止时间之后到达的成功回调不再记录，事务只能朝 cancel 推进
+		if _, err = t.advanceProgressByTXID(log.WithTXID(context.WithoutCancel(ctx), txID), txID); err != nil {
+			return err
+		}
+		return componentError(componentID, PhaseTry, fmt.Errorf("%w: tx: %s is past its deadline", ErrStoreConflict, txID))
+	default:
+		if err = t.txStore.TXUpdate(log.WithTXID(ctx, txID), txID, componentID, ack); err != nil {
+			return err
+		}
+	}
+
+	// 后续组件的执行不受回调方 ctx 取消的影响
+	ctx = log.WithTXID(context.WithoutCancel(ctx), txID)
+	if ack {
+		if err = t.resumeTries(ctx, txID, componentID); err != nil {
+			return err
+		}
+	}
+	_, err = t.advanceProgressByTXID(ctx, txID)
+	return err
+}
+
+// 续跑依赖于 componentID 的后续组件. 这些组件在其依赖的异步 try 回调之前没有执行 try，
+// 基于事务日志重建其请求，仍依赖于其他等待回调的组件时跳过，待对应的回调到达后再续跑
+func (t *TXManager) resumeTries(ctx context.Context, txID, componentID string) error {
+	tx, err := t.txStore.GetTX(ctx, txID)
+	if err != nil {
+		return err
+	}
+	// 已经超过截止时间的事务不再续跑，交由推进流程 cancel
+	now := time.Now()
+	if tx.Status != TXHanging || tx.getStatus(now, t.opts.Timeout) != TXHanging {
+		return nil
+	}
+	deadline := tx.deadline(t.opts.Timeout)
+	graph, err := tx.graph()
+	if err != nil {
+		return err
+	}
+	ordered, err := graph.order()
+	if err != nil {
+		return err
+	}
+
+	// 按照拓扑序找出直接或者间接依赖于 componentID、并且尚未得出结果的组件
+	resumed := map[string]bool{componentID: true}
+	var entities ComponentEntities
+	for _, id := range ordered {
+		component := tx.component(id)
+		if component.TryStatus != TryHanging {
+			continue
+		}
+		for _, dep := range graph.deps[id] {
+			resumed[id] = resumed[id] || resumed[dep]
+		}
+		if !resumed[id] {
+			continue
+		}
+		entity, err := t.resumeEntity(ctx, tx, component, deadline.Sub(now))
+		if err != nil {
+			return err
+		}
+		entities = append(entities, entity)
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+
+	settled := make(map[string]*TCCResp, len(tx.Components))
+	for _, component := range tx.Components {
+		if component.TryStatus != TrySucceesful {
+			continue
+		}
+		resp := TCCResp{ComponentID: component.ComponentID, TXID: txID, ACK: true, Reason: component.Reason}
+		// 执行结果的载荷无法解码时忽略
+		if component.Result != nil {
+			_ = component.Result.Decode(&resp.Result)
+		}
+		settled[component.ComponentID] = &resp
+	}
+
+	// 续跑的组件失败时已记录到事务日志，由推进流程 cancel
+	if _, err = t.tryBranches(ctx, txID, graph, entities, settled); err != nil {
+		t.log(ctx, log.LevelWarn, "tx resume tries failed", log.Err(err))
+	}
+	return nil
+}
+
+// 基于事务日志重建组件的 try 请求. 超时时长不超过事务剩余的时长
+func (t *TXManager) resumeEntity(ctx context.Context, tx *Transaction, component *ComponentTryEntity, remaining time.Duration) (*ComponentEntity, error) {
+	tccComponent, err := t.registryCenter.getComponent(ctx, component.ComponentID)
+	if err != nil {
+		return nil, err
+	}
+	var request map[string]interface{}
+	if component.Request != nil {
+		if err = component.Request.Decode(&request); err != nil {
+			return nil, componentError(component.ComponentID, PhaseTry, fmt.Errorf("%w: decode request failed: %w", ErrInvalidRequest, err))
+		}
+	}
+	timeout := component.Timeout
+	if timeout <= 0 {
+		timeout = t.componentTimeout(component.ComponentID, PhaseTry)
+	}
+	if timeout > remaining {
+		timeout = remaining
+	}
+
+	entity := ComponentEntity{
+		Request:   request,
+		Component: tccComponent,
+		Timeout:   timeout,
+		Stage:     component.Stage,
+		DependsOn: component.DependsOn,
+		Saga:      component.Saga,
+	}
+	if component.BuildRequest {
+		// 构造方法仅保留在开启事务的节点上，无法获取时该组件视为失败
+		entity.BuildRequest = t.pendingTries.get(tx.TXID, component.ComponentID)
+		if entity.BuildRequest == nil {
+			entity.BuildRequest = func(map[string]interface{}, map[string]*TCCResp) (map[string]interface{}, error) {
+				return nil, errors.New("build request is not available on this node")
+			}
+		}
+	}
+	return &entity, nil
+}
+
+// 本节点上开启的、存在异步 try 的事务中各组件的请求. 请求参数的构造方法无法持久化，
+// 在此保留至事务截止时间，供本节点接收回调后续跑后续组件时使用
+type pendingTries struct {
+	mux      sync.Mutex
+	entities map[string]ComponentEntities
+}
+
+func newPendingTries() *pendingTries {
+	return &pendingTries{
+		entities: make(map[string]ComponentEntities),
+	}
+}
+
+func (p *pendingTries) add(txID string, entities ComponentEntities, deadline time.Time) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.entities[txID] = entities
+	time.AfterFunc(time.Until(deadline), func() {
+		p.mux.Lock()
+		defer p.mux.Unlock()
+		delete(p.entities, txID)
+	})
+}
+
+// 获取组件请求参数的构造方法，不存在时返回 nil
+func (p *pendingTries) get(txID, componentID string) func(request map[string]interface{}, deps map[string]*TCCResp) (map[string]interface{}, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for _, entity := range p.entities[txID] {
+		if entity.Component.ID() == componentID {
+			return entity.BuildRequest
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 预留资源后 try 响应 pending，由测试用例回调结果
+type pendingComponent struct {
+	TCCComponent
+}
+
+func (p *pendingComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	if _, err := p.TCCComponent.Try(ctx, req); err != nil {
+		return nil, err
+	}
+	return &TCCResp{ComponentID: p.ID(), TXID: req.TXID, Pending: true}, nil
+}
+
+func Test_txManager_pending(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  bool
+		ack     bool
+		success bool
+		calls   []string
+	}{
+		{
+			name:    "ack",
+			report:  true,
+			ack:     true,
+			success: true,
+			calls:   []string{"b:try", "a:confirm", "b:confirm"},
+		},
+		{
+			name:   "reject",
+			report: true,
+			calls:  []string{"b:cancel", "a:cancel"},
+		},
+		{
+			// 超时仍未回调时视为失败
+			name:  "timeout",
+			calls: []string{"b:cancel", "a:cancel"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+			defer txManager.Stop()
+			var r recorder
+			assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: &pendingComponent{TCCComponent: newMockComponent("a")}, recorder: &r}))
+			assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("b"), recorder: &r}))
+
+			// 异步 try 不阻塞事务，依赖于它的组件暂不执行 try
+			ctx := context.Background()
+			result, err := txManager.Transaction(ctx, []*RequestEntity{
+				{ComponentID: "a", Timeout: 100 * time.Millisecond},
+				{ComponentID: "b", DependsOn: []string{"a"}, Timeout: 10 * time.Millisecond},
+			})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, false, result.Success)
+			assert.Equal(t, nil, result.Err)
+			assert.Equal(t, TXHanging, result.Status)
+			assert.Equal(t, true, result.Component("a").Pending)
+			assert.Equal(t, TryHanging, result.Component("b").TryStatus)
+			assert.Equal(t, []string{"a:try"}, r.calls)
+
+			// 回调后续跑依赖于它的组件并推进事务
+			if tt.report {
+				assert.Equal(t, nil, txManager.ReportTry(ctx, result.TXID, "a", tt.ack))
+			} else {
+				// 超过整条链路的截止时间
+				<-time.After(110 * time.Millisecond)
+				_, err = txManager.advanceProgressByTXID(ctx, result.TXID)
+				assert.Equal(t, nil, err)
+			}
+			assert.Equal(t, append([]string{"a:try"}, tt.calls...), r.calls)
+			tx, err := txManager.GetTX(ctx, result.TXID)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.success, tx.Status == TXSuccessful)
+
+			// 事务结束后的回调与已记录的结果冲突
+			err = txManager.ReportTry(ctx, result.TXID, "a", !tt.ack)
+			assert.Equal(t, true, errors.Is(err, ErrStoreConflict))
+		})
+	}
+}
+
+func Test_txManager_pending_late(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var r recorder
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: &pendingComponent{TCCComponent: newMockComponent("a")}, recorder: &r}))
+
+	ctx := context.Background()
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a", Timeout: 20 * time.Millisecond}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXHanging, result.Status)
+
+	// 超过截止时间之后的成功回调被拒绝，事务随之 cancel 而不是 confirm
+	<-time.After(60 * time.Millisecond)
+	err = txManager.ReportTry(ctx, result.TXID, "a", true)
+	assert.Equal(t, true, errors.Is(err, ErrStoreConflict))
+	assert.Equal(t, []string{"a:try", "a:cancel"}, r.calls)
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXFailure, tx.Status)
+	assert.Equal(t, TryHanging, tx.component("a").TryStatus)
+}
+
+func Test_txManager_pending_resume(t *testing.T) {
+	txStore := newMockTXStore()
+	nodeA := NewTXManager(txStore, WithMonitorTick(time.Hour))
+	defer nodeA.Stop()
+	nodeB := NewTXManager(txStore, WithMonitorTick(time.Hour))
+	defer nodeB.Stop()
+	var r recorder
+	components := []TCCComponent{
+		&recordComponent{TCCComponent: &pendingComponent{TCCComponent: newMockComponent("a")}, recorder: &r},
+		&recordComponent{TCCComponent: newMockComponent("b"), recorder: &r},
+		&recordComponent{TCCComponent: newMockComponent("c"), recorder: &r},
+	}
+	// 两个节点访问同一组参与方
+	for _, node := range []*TXManager{nodeA, nodeB} {
+		for _, component := range components {
+			assert.Equal(t, nil, node.Register(component))
+		}
+	}
+	ctx := context.Background()
+
+	// 回调由其他节点接收时，基于事务日志重建后续组件的请求；请求参数的构造方法仅保留在开启事务的节点上
+	build := func(request map[string]interface{}, deps map[string]*TCCResp) (map[string]interface{}, error) {
+		return request, nil
+	}
+	tests := []struct {
+		name    string
+		node    *TXManager
+		build   bool
+		success bool
+		calls   []string
+	}{
+		{
+			name:    "other node",
+			node:    nodeB,
+			success: true,
+			calls:   []string{"a:try", "b:try", "c:try", "a:confirm", "b:confirm", "c:confirm"},
+		},
+		{
+			name:    "build request on origin node",
+			node:    nodeA,
+			build:   true,
+			success: true,
+			calls:   []string{"a:try", "b:try", "c:try", "a:confirm", "b:confirm", "c:confirm"},
+		},
+		{
+			name:  "build request on other node",
+			node:  nodeB,
+			build: true,
+			calls: []string{"a:try", "b:try", "c:cancel", "b:cancel", "a:cancel"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.calls = nil
+			c := RequestEntity{ComponentID: "c", Stage: 1, Request: map[string]interface{}{"amount": 1}}
+			if tt.build {
+				c.BuildRequest = build
+			}
+			result, err := nodeA.Transaction(ctx, []*RequestEntity{
+				{ComponentID: "a", Timeout: time.Second},
+				{ComponentID: "b", DependsOn: []string{"a"}},
+				&c,
+			})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, TXHanging, result.Status)
+
+			assert.Equal(t, nil, tt.node.ReportTry(ctx, result.TXID, "a", true))
+			tx, err := tt.node.GetTX(ctx, result.TXID)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.success, tx.Status == TXSuccessful)
+			assert.Equal(t, tt.calls, r.calls)
+
+			// 重复回调相同的结果视为成功
+			assert.Equal(t, nil, tt.node.ReportTry(ctx, result.TXID, "a", true))
+			assert.Equal(t, tt.calls, r.calls)
+		})
+	}
+}
+
+func Test_txManager_ReportTry(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore, WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var r recorder
+	component := recordComponent{TCCComponent: newMockComponent("a"), recorder: &r}
+	assert.Equal(t, nil, txManager.Register(&component))
+	ctx := context.Background()
+
+	// 本节点上没有等待中的 try 时，回调后直接推进事务
+	tx := Transaction{
+		TXID:       "pending",
+		Status:     TXHanging,
+		CreatedAt:  time.Now(),
+		Deadline:   time.Now().Add(time.Minute),
+		Components: []*ComponentTryEntity{{ComponentID: "a", TryStatus: TryHanging}},
+	}
+	_, err := txStore.(TXDetailStore).CreateTXDetail(ctx, &tx)
+	assert.Equal(t, nil, err)
+	_, err = component.TCCComponent.Try(ctx, &TCCReq{ComponentID: "a", TXID: "pending"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXHanging, tx.getStatus(time.Now(), time.Minute))
+
+	assert.Equal(t, nil, txManager.ReportTry(ctx, "pending", "a", true))
+	assert.Equal(t, []string{"a:confirm"}, r.calls)
+	got, err := txManager.GetTX(ctx, "pending")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXSuccessful, got.Status)
+
+	// 重复回调相同的结果视为成功
+	assert.Equal(t, nil, txManager.ReportTry(ctx, "pending", "a", true))
+	assert.Equal(t, []string{"a:confirm"}, r.calls)
+
+	assert.Equal(t, true, errors.Is(txManager.ReportTry(ctx, "unknown", "a", true), ErrTXNotFound))
+	assert.Equal(t, true, errors.Is(txManager.ReportTry(ctx, "pending", "b", true), ErrComponentNotFound))
+}
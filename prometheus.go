@@ -0,0 +1,244 @@
+package gotcc
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认的耗时分桶，单位为秒
+var defaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// 基于 prometheus 文本协议暴露指标的 Metrics 实现，本身即为一个 http.Handler
+type PrometheusMetrics struct {
+	mux     sync.Mutex
+	metrics []*promMetric
+
+	txStarted       *promMetric
+	txFinished      *promMetric
+	txDuration      *promMetric
+	componentCalls  *promMetric
+	componentCost   *promMetric
+	hangingTXs      *promMetric
+	monitorCost     *promMetric
+	monitorFailures *promMetric
+	lockFailures    *promMetric
+	backOffLevel    *promMetric
+}
+
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	if namespace == "" {
+		namespace = "gotcc"
+	}
+
+	p := PrometheusMetrics{}
+	p.txStarted = p.newMetric(namespace+"_tx_started_total", "Total number of started transactions.", "counter")
+	p.txFinished = p.newMetric(namespace+"_tx_finished_total", "Total number of finished transactions by status.", "counter", "status")
+	p.txDuration = p.newMetric(namespace+"_tx_duration_seconds", "Duration from transaction creation to its final status.", "histogram", "status")
+	p.componentCalls = p.newMetric(namespace+"_component_calls_total", "Total number of component calls by phase and result.", "counter", "component", "phase", "result")
+	p.componentCost = p.newMetric(namespace+"_component_call_duration_seconds", "Latency of component calls by phase.", "histogram", "component", "phase")
+	p.hangingTXs = p.newMetric(namespace+"_hanging_txs", "Number of hanging transactions seen by the last monitor tick.", "gauge")
+	p.monitorCost = p.newMetric(namespace+"_monitor_tick_duration_seconds", "Duration of monitor ticks.", "histogram")
+	p.monitorFailures = p.newMetric(namespace+"_monitor_tick_failures_total", "Total number of monitor ticks that ended with an error.", "counter")
+	p.lockFailures = p.newMetric(namespace+"_lock_failures_total", "Total number of failed attempts to acquire the txstore lock.", "counter")
+	p.backOffLevel = p.newMetric(namespace+"_monitor_backoff_level", "Current back off level of the monitor, 0 means no back off.", "gauge")
+	return &p
+}
+
+func (p *PrometheusMetrics) newMetric(name, help, typ string, labelNames ...string) *promMetric {
+	m := promMetric{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		series:     make(map[string]*promSeries),
+	}
+	p.metrics = append(p.metrics, &m)
+	return &m
+}
+
+func (p *PrometheusMetrics) TXStarted() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.txStarted.add(1)
+}
+
+func (p *PrometheusMetrics) TXFinished(status TXStatus, cost time.Duration) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.txFinished.add(1, status.String())
+	p.txDuration.observe(cost.Seconds(), status.String())
+}
+
+func (p *PrometheusMetrics) ComponentCalled(componentID string, phase Phase, ack bool, err error, cost time.Duration) {
+	result := "ack"
+	if err != nil {
+		result = "error"
+	} else if !ack {
+		result = "reject"
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.componentCalls.add(1, componentID, phase.String(), result)
+	p.componentCost.observe(cost.Seconds(), componentID, phase.String())
+}
+
+func (p *PrometheusMetrics) HangingTXs(cnt int) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.hangingTXs.set(float64(cnt))
+}
+
+func (p *PrometheusMetrics) MonitorTicked(cost time.Duration, err error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.monitorCost.observe(cost.Seconds())
+	if err != nil {
+		p.monitorFailures.add(1)
+	}
+}
+
+func (p *PrometheusMetrics) LockFailed(err error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.lockFailures.add(1)
+}
+
+func (p *PrometheusMetrics) BackOffLevel(level int) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.backOffLevel.set(float64(level))
+}
+
+// 以 prometheus 文本协议输出全量指标
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	p.mux.Lock()
+	for _, m := range p.metrics {
+		m.writeTo(&b)
+	}
+	p.mux.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+type promMetric struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	series     map[string]*promSeries
+}
+
+type promSeries struct {
+	labelValues []string
+	value       float64
+	// 以下字段仅 histogram 使用
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func (m *promMetric) get(labelValues ...string) *promSeries {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &promSeries{labelValues: labelValues}
+		if m.typ == "histogram" {
+			s.buckets = make([]uint64, len(defaultDurationBuckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+func (m *promMetric) add(delta float64, labelValues ...string) {
+	m.get(labelValues...).value += delta
+}
+
+func (m *promMetric) set(value float64, labelValues ...string) {
+	m.get(labelValues...).value = value
+}
+
+func (m *promMetric) observe(value float64, labelValues ...string) {
+	s := m.get(labelValues...)
+	for i, upper := range defaultDurationBuckets {
+		if value <= upper {
+			s.buckets[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (m *promMetric) writeTo(b *strings.Builder) {
+	if len(m.series) == 0 && len(m.labelNames) > 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", m.name, m.typ)
+	// 没有标签的指标，即便从未上报过也输出零值
+	if len(m.series) == 0 {
+		m.get()
+	}
+
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := m.series[key]
+		if m.typ != "histogram" {
+			fmt.Fprintf(b, "%s%s %s\n", m.name, m.labels(s.labelValues), formatFloat(s.value))
+			continue
+		}
+
+		for i, upper := range defaultDurationBuckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", m.name, m.labels(s.labelValues, "le", formatFloat(upper)), s.buckets[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", m.name, m.labels(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", m.name, m.labels(s.labelValues), formatFloat(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", m.name, m.labels(s.labelValues), s.count)
+	}
+}
+
+func (m *promMetric) labels(labelValues []string, extra ...string) string {
+	pairs := make([]string, 0, len(labelValues)+len(extra)/2)
+	for i, value := range labelValues {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", m.labelNames[i], escapeLabelValue(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer("\\", `\\`, "\n", `\n`, "\"", `\"`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,75 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics("")
+	metrics.TXStarted()
+	metrics.TXFinished(TXSuccessful, 20*time.Millisecond)
+	metrics.ComponentCalled("a", PhaseTry, true, nil, time.Millisecond)
+	metrics.ComponentCalled("a", PhaseTry, false, nil, time.Millisecond)
+	metrics.ComponentCalled("b\"", PhaseCancel, false, errors.New("err"), time.Second)
+	metrics.HangingTXs(3)
+	metrics.MonitorTicked(time.Millisecond, errors.New("err"))
+	metrics.LockFailed(errors.New("lock held"))
+	metrics.BackOffLevel(2)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	assert.Equal(t, true, strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain"))
+	for _, line := range []string{
+		"# TYPE gotcc_tx_started_total counter",
+		"gotcc_tx_started_total 1",
+		`gotcc_tx_finished_total{status="successful"} 1`,
+		`gotcc_tx_duration_seconds_bucket{status="successful",le="0.025"} 1`,
+		`gotcc_tx_duration_seconds_bucket{status="successful",le="0.01"} 0`,
+		`gotcc_tx_duration_seconds_count{status="successful"} 1`,
+		`gotcc_component_calls_total{component="a",phase="try",result="ack"} 1`,
+		`gotcc_component_calls_total{component="a",phase="try",result="reject"} 1`,
+		`gotcc_component_calls_total{component="b\"",phase="cancel",result="error"} 1`,
+		`gotcc_component_call_duration_seconds_bucket{component="a",phase="try",le="+Inf"} 2`,
+		"gotcc_hanging_txs 3",
+		"gotcc_monitor_tick_failures_total 1",
+		"gotcc_lock_failures_total 1",
+		"gotcc_monitor_backoff_level 2",
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}
+
+func Test_txmanager_metrics(t *testing.T) {
+	metrics := NewPrometheusMetrics("tcc")
+	txmanager := NewTXManager(newMockTXStore(), WithMetrics(metrics))
+	defer txmanager.Stop()
+
+	if err := txmanager.Register(newMockComponent("a")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	result, err := txmanager.Transaction(context.Background(), []*RequestEntity{{ComponentID: "a"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, true, result.Success)
+
+	var b strings.Builder
+	_, _ = metrics.WriteTo(&b)
+	body := b.String()
+	assert.Contains(t, body, "tcc_tx_started_total 1\n")
+	assert.Contains(t, body, `tcc_tx_finished_total{status="successful"} 1`+"\n")
+	assert.Contains(t, body, `tcc_component_calls_total{component="a",phase="try",result="ack"} 1`+"\n")
+	assert.Contains(t, body, `tcc_component_calls_total{component="a",phase="confirm",result="ack"} 1`+"\n")
+}
@@ -0,0 +1,164 @@
+package gotcc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 组件的访问地址
+type ComponentEndpoint struct {
+	ComponentID string `json:"componentID"`
+	// 组件的访问地址，具体格式由 Dialer 决定，例如 httptransport 使用的 baseURL
+	Endpoint string `json:"endpoint"`
+	Version  uint64 `json:"version"`
+}
+
+// 组件注册中心，用于在多个节点之间共享组件的访问地址.
+// 本地未注册的组件会通过 Registry 查询其地址，再由 Dialer 构造出对应的 tcc 组件，
+// 从而使得任意节点的轮询任务都能够推进任意一笔事务
+type Registry interface {
+	// 注册或更新组件的访问地址，版本号低于已注册版本时拒绝更新
+	Register(ctx context.Context, endpoint *ComponentEndpoint) error
+	// 注销组件
+	Deregister(ctx context.Context, componentID string) error
+	// 查询组件的访问地址
+	Lookup(ctx context.Context, componentID string) (*ComponentEndpoint, error)
+}
+
+// 基于组件的访问地址构造出 tcc 组件，例如 httptransport.Dialer
+type Dialer func(endpoint *ComponentEndpoint) (TCCComponent, error)
+
+// 基于文件的组件注册中心，可通过共享存储在多个节点之间共享. 文件内容为 componentID -> ComponentEndpoint 的 json.
+// 写入时先写临时文件再重命名，保证读取方不会读到写了一半的内容；读改写的过程通过 {path}.lock 锁文件在多个进程之间互斥，
+// 避免并发的更新相互覆盖
+type FileRegistry struct {
+	path string
+	mux  sync.Mutex
+}
+
+const (
+	// 锁文件超过该时长未被释放时视为持有方已异常退出，允许其他进程清理后重新加锁
+	fileRegistryLockStale = 10 * time.Second
+	// 锁被占用时的重试间隔
+	fileRegistryLockRetry = 10 * time.Millisecond
+)
+
+func NewFileRegistry(path string) *FileRegistry {
+	return &FileRegistry{path: path}
+}
+
+func (f *FileRegistry) Register(ctx context.Context, endpoint *ComponentEndpoint) error {
+	return f.update(ctx, func(endpoints map[string]*ComponentEndpoint) error {
+		if registered, ok := endpoints[endpoint.ComponentID]; ok && registered.Version > endpoint.Version {
+			return componentError(endpoint.ComponentID, "", fmt.Errorf("%w, version: %d, registered version: %d", ErrStaleVersion, endpoint.Version, registered.Version))
+		}
+		endpoints[endpoint.ComponentID] = endpoint
+		return nil
+	})
+}
+
+func (f *FileRegistry) Deregister(ctx context.Context, componentID string) error {
+	return f.update(ctx, func(endpoints map[string]*ComponentEndpoint) error {
+		delete(endpoints, componentID)
+		return nil
+	})
+}
+
+func (f *FileRegistry) Lookup(ctx context.Context, componentID string) (*ComponentEndpoint, error) {
+	endpoints, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	endpoint, ok := endpoints[componentID]
+	if !ok {
+		return nil, componentError(componentID, "", ErrComponentNotFound)
+	}
+	return endpoint, nil
+}
+
+func (f *FileRegistry) load() (map[string]*ComponentEndpoint, error) {
+	endpoints := make(map[string]*ComponentEndpoint)
+	body, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return endpoints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return endpoints, nil
+	}
+	if err = json.Unmarshal(body, &endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (f *FileRegistry) update(ctx context.Context, do func(endpoints map[string]*ComponentEndpoint) error) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	endpoints, err := f.load()
+	if err != nil {
+		return err
+	}
+	if err = do(endpoints); err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(endpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(body); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// 以独占的方式创建锁文件，创建成功即持有跨进程的写锁. 锁被占用时重试，直到 ctx 结束
+func (f *FileRegistry) lock(ctx context.Context) (func(), error) {
+	lockPath := f.path + ".lock"
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = file.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		// 持有方异常退出遗留的锁文件，清理后重新加锁
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > fileRegistryLockStale {
+			_ = os.Remove(lockPath)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: registry file: %s, err: %w", ErrLockHeld, f.path, ctx.Err())
+		case <-time.After(fileRegistryLockRetry):
+		}
+	}
+}
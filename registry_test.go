@@ -0,0 +1,123 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FileRegistry(t *testing.T) {
+	registry := NewFileRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	ctx := context.Background()
+
+	_, err := registry.Lookup(ctx, "a")
+	assert.NotEqual(t, nil, err)
+
+	assert.Equal(t, nil, registry.Register(ctx, &ComponentEndpoint{ComponentID: "a", Endpoint: "ep1", Version: 1}))
+	endpoint, err := registry.Lookup(ctx, "a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "ep1", endpoint.Endpoint)
+
+	// 低版本不能覆盖高版本
+	assert.NotEqual(t, nil, registry.Register(ctx, &ComponentEndpoint{ComponentID: "a", Endpoint: "ep0"}))
+	assert.Equal(t, nil, registry.Register(ctx, &ComponentEndpoint{ComponentID: "a", Endpoint: "ep2", Version: 2}))
+	endpoint, err = registry.Lookup(ctx, "a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "ep2", endpoint.Endpoint)
+
+	assert.Equal(t, nil, registry.Deregister(ctx, "a"))
+	_, err = registry.Lookup(ctx, "a")
+	assert.NotEqual(t, nil, err)
+}
+
+func Test_FileRegistry_concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	ctx := context.Background()
+
+	// 各个 FileRegistry 实例模拟共享同一个文件的不同进程，并发的更新不会相互覆盖
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			componentID := fmt.Sprintf("c%d", i)
+			assert.Equal(t, nil, NewFileRegistry(path).Register(ctx, &ComponentEndpoint{ComponentID: componentID, Endpoint: componentID}))
+		}()
+	}
+	wg.Wait()
+
+	registry := NewFileRegistry(path)
+	for i := 0; i < 20; i++ {
+		_, err := registry.Lookup(ctx, fmt.Sprintf("c%d", i))
+		assert.Equal(t, nil, err)
+	}
+
+	// 锁被占用时等待，直到 ctx 结束
+	lockPath := path + ".lock"
+	assert.Equal(t, nil, os.WriteFile(lockPath, nil, 0o644))
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, true, errors.Is(registry.Deregister(tctx, "c0"), ErrLockHeld))
+
+	// 异常退出遗留的锁文件过期后被清理
+	stale := time.Now().Add(-time.Minute)
+	assert.Equal(t, nil, os.Chtimes(lockPath, stale, stale))
+	assert.Equal(t, nil, registry.Deregister(ctx, "c0"))
+	_, err := os.Stat(lockPath)
+	assert.Equal(t, true, errors.Is(err, os.ErrNotExist))
+}
+
+func Test_txManager_registry(t *testing.T) {
+	registry := NewFileRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	components := map[string]TCCComponent{
+		"ep1": newMockComponent("a"),
+		"ep2": newMockComponent("a"),
+	}
+	var dialed []string
+	dialer := func(endpoint *ComponentEndpoint) (TCCComponent, error) {
+		dialed = append(dialed, endpoint.Endpoint)
+		return components[endpoint.Endpoint], nil
+	}
+
+	// 节点 a 在本地注册组件，并发布其访问地址
+	txStore := newMockTXStore()
+	nodeA := NewTXManager(txStore, WithRegistry(registry, dialer))
+	defer nodeA.Stop()
+	assert.Equal(t, nil, nodeA.Register(components["ep1"], WithEndpoint("ep1")))
+
+	// 节点 b 未注册组件，通过注册中心获取
+	nodeB := NewTXManager(txStore, WithRegistry(registry, dialer))
+	defer nodeB.Stop()
+	ctx := context.Background()
+	result, err := nodeB.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	tx, err := nodeB.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXSuccessful, tx.Status)
+	assert.Equal(t, []string{"ep1"}, dialed)
+
+	// 地址未变化时复用已构造的组件，变化后重新构造
+	_, err = nodeB.registryCenter.getComponent(ctx, "a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"ep1"}, dialed)
+	assert.Equal(t, nil, nodeA.Replace(components["ep2"], WithEndpoint("ep2")))
+	component, err := nodeB.registryCenter.getComponent(ctx, "a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, components["ep2"], component)
+	assert.Equal(t, []string{"ep1", "ep2"}, dialed)
+
+	// 远端组件不能在本地注销，本地注销时一并注销注册中心中的地址
+	assert.NotEqual(t, nil, nodeB.Unregister(ctx, "a"))
+	assert.Equal(t, nil, nodeA.Unregister(ctx, "a"))
+	_, err = registry.Lookup(ctx, "a")
+	assert.NotEqual(t, nil, err)
+}
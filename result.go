@@ -0,0 +1,77 @@
+package gotcc
+
+import "time"
+
+// 事务的执行结果
+type TXResult struct {
+	TXID string
+	// try 阶段是否全部成功，即事务最终会被 confirm 还是 cancel. 需要审批的事务还取决于审批结果；
+	// 存在等待参与方回调的异步 try 时为 false，最终结果以回调后的事务状态为准
+	Success bool
+	// 事务的状态. 第二阶段未在本次调用内执行完成时为 hanging，后续由轮询任务兜底推进；等待审批时为 awaiting-approval
+	Status TXStatus
+	// try 阶段失败的原因，即首个失败组件的错误
+	Err error
+	// 各组件 try 请求的执行结果，顺序与事务中的组件一致
+	Components []*ComponentResult
+	// 开启事务的时间
+	StartedAt time.Time
+	// try 阶段的耗时
+	TryDuration time.Duration
+	// 第二阶段的耗时，仅在第二阶段已在本次调用内执行完成时有值
+	Phase2Duration time.Duration
+	// 第二阶段是否已在本次调用内执行完成. 为 false 时第二阶段交由轮询任务处理
+	Phase2Done bool
+}
+
+// 组件 try 请求的执行结果
+type ComponentResult struct {
+	ComponentID string
+	// try 请求的状态. 因依赖的组件失败或者等待回调而未执行 try 的组件为 hanging
+	TryStatus ComponentTryStatus
+	// try 响应了 pending，正在等待参与方回调结果. 此时事务处于 hanging 状态，回调后继续执行依赖于该组件的后续组件
+	Pending bool
+	// try 请求报错或者被拒绝时的错误
+	Err error
+	// try 的执行结果
+	Result map[string]interface{}
+	// 拒绝原因
+	Reason *RejectReason
+	// try 请求的耗时
+	Duration time.Duration
+}
+
+// 获取指定组件的执行结果，组件不存在时返回 nil
+func (t *TXResult) Component(componentID string) *ComponentResult {
+	for _, component := range t.Components {
+		if component.ComponentID == componentID {
+			return component
+		}
+	}
+	return nil
+}
+
+// 基于事务日志构造事务执行结果，执行结果的载荷无法解码时忽略.
+// 事务日志中不包含错误与耗时，对应字段为空
+func txResultOf(tx *Transaction, success bool) *TXResult {
+	result := TXResult{
+		TXID:       tx.TXID,
+		Success:    success,
+		Status:     tx.Status,
+		Components: make([]*ComponentResult, 0, len(tx.Components)),
+		StartedAt:  tx.CreatedAt,
+		Phase2Done: tx.Status.finished(),
+	}
+	for _, component := range tx.Components {
+		componentResult := ComponentResult{
+			ComponentID: component.ComponentID,
+			TryStatus:   component.TryStatus,
+			Reason:      component.Reason,
+		}
+		if component.Result != nil {
+			_ = component.Result.Decode(&componentResult.Result)
+		}
+		result.Components = append(result.Components, &componentResult)
+	}
+	return &result
+}
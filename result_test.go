@@ -0,0 +1,103 @@
+package gotcc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 以指定的原因拒绝 try 请求
+type reasonComponent struct {
+	TCCComponent
+	reason *RejectReason
+}
+
+func (r *reasonComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	return &TCCResp{ComponentID: r.ID(), TXID: req.TXID, Reason: r.reason}, nil
+}
+
+func Test_txManager_result(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore())
+	defer txManager.Stop()
+	var r recorder
+	reason := RejectReason{Code: "out_of_stock", Message: "inventory not enough"}
+	assert.Equal(t, nil, txManager.Register(&resultComponent{TCCComponent: newMockComponent("inventory"), recorder: &r}))
+	assert.Equal(t, nil, txManager.Register(&reasonComponent{TCCComponent: newMockComponent("coupon"), reason: &reason}))
+
+	t.Run("success", func(t *testing.T) {
+		result, err := txManager.Transaction(context.Background(), []*RequestEntity{{ComponentID: "inventory"}},
+			WithIdempotencyKey("success"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, result.Success)
+		reservation := map[string]interface{}{"reservation": "inventory_" + result.TXID}
+		assert.Equal(t, TrySucceesful, result.Component("inventory").TryStatus)
+		assert.Equal(t, reservation, result.Component("inventory").Result)
+
+		// 执行结果随事务日志持久化，重复请求得到相同的执行结果
+		again, err := txManager.Transaction(context.Background(), []*RequestEntity{{ComponentID: "inventory"}},
+			WithIdempotencyKey("success"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, result.TXID, again.TXID)
+		assert.Equal(t, reservation, again.Component("inventory").Result)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		result, err := txManager.Transaction(context.Background(), []*RequestEntity{
+			{ComponentID: "inventory", Stage: 1},
+			{ComponentID: "coupon"},
+		})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, result.Success)
+		assert.Equal(t, TryFailure, result.Component("coupon").TryStatus)
+		assert.Equal(t, &reason, result.Component("coupon").Reason)
+		// 前序组件失败，后续组件不再执行 try
+		assert.Equal(t, TryHanging, result.Component("inventory").TryStatus)
+
+		tx, err := txManager.GetTX(context.Background(), result.TXID)
+		assert.Equal(t, nil, err)
+		for _, component := range tx.Components {
+			if component.ComponentID == "coupon" {
+				assert.Equal(t, &reason, component.Reason)
+			}
+		}
+	})
+}
+
+func Test_txManager_result_phase2(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var canceled []string
+	assert.Equal(t, nil, txManager.Register(&flakyComponent{TCCComponent: newMockComponent("a"), failures: 1, canceled: &canceled}))
+	assert.Equal(t, nil, txManager.Register(newMockComponent("b")))
+
+	// 第二阶段在本次调用内执行完成
+	result, err := txManager.Transaction(context.Background(), []*RequestEntity{{ComponentID: "b"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, TXSuccessful, result.Status)
+	assert.Equal(t, true, result.Phase2Done)
+	assert.Equal(t, false, result.StartedAt.IsZero())
+	assert.Equal(t, true, result.TryDuration > 0)
+	assert.Equal(t, true, result.Phase2Duration > 0)
+
+	// confirm 未被确认时，第二阶段交由轮询任务处理
+	result, err = txManager.Transaction(context.Background(), []*RequestEntity{{ComponentID: "a"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, TXHanging, result.Status)
+	assert.Equal(t, false, result.Phase2Done)
+	assert.Equal(t, time.Duration(0), result.Phase2Duration)
+
+	// try 失败时返回首个失败组件的错误
+	result, err = txManager.Transaction(context.Background(), []*RequestEntity{
+		{ComponentID: "b", Request: map[string]interface{}{"reject_flag": true}},
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, result.Success)
+	assert.Equal(t, TXFailure, result.Status)
+	assert.NotEqual(t, nil, result.Err)
+	assert.Equal(t, result.Err, result.Component("b").Err)
+	assert.Equal(t, true, result.Component("b").Duration > 0)
+}
@@ -0,0 +1,50 @@
+package gotcc
+
+import "context"
+
+// saga 参与方. 只提供正向操作以及对应的补偿操作，没有资源预留的环节
+type SagaComponent interface {
+	// 返回组件唯一 id
+	ID() string
+	// 执行正向操作
+	Execute(ctx context.Context, req *TCCReq) (*TCCResp, error)
+	// 执行补偿操作. 需要保证幂等，并且允许正向操作未执行时的空补偿
+	Compensate(ctx context.Context, txID string) (*TCCResp, error)
+}
+
+// 把 saga 参与方适配为 tcc 组件，可以与 tcc 组件在同一笔事务中混合使用:
+// try 执行正向操作，confirm 直接确认，cancel 执行补偿操作.
+// 同一笔事务中的 saga 分支按照请求的先后顺序依次执行，事务失败时按照逆序进行补偿
+func NewSagaComponent(component SagaComponent) TCCComponent {
+	return &sagaComponent{component: component}
+}
+
+type sagaComponent struct {
+	component SagaComponent
+}
+
+func (s *sagaComponent) ID() string {
+	return s.component.ID()
+}
+
+func (s *sagaComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	return s.component.Execute(ctx, req)
+}
+
+// 正向操作已经生效，无需再确认
+func (s *sagaComponent) Confirm(ctx context.Context, txID string) (*TCCResp, error) {
+	return &TCCResp{
+		ComponentID: s.component.ID(),
+		TXID:        txID,
+		ACK:         true,
+	}, nil
+}
+
+func (s *sagaComponent) Cancel(ctx context.Context, txID string) (*TCCResp, error) {
+	return s.component.Compensate(ctx, txID)
+}
+
+func isSaga(component TCCComponent) bool {
+	_, ok := component.(*sagaComponent)
+	return ok
+}
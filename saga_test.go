@@ -0,0 +1,97 @@
+package gotcc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSagaComponent struct {
+	id       string
+	recorder *recorder
+}
+
+func (m *mockSagaComponent) ID() string {
+	return m.id
+}
+
+func (m *mockSagaComponent) Execute(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	m.recorder.record(m.id, "execute")
+	return &TCCResp{ComponentID: m.id, TXID: req.TXID, ACK: req.Data["reject_flag"] != true}, nil
+}
+
+func (m *mockSagaComponent) Compensate(ctx context.Context, txID string) (*TCCResp, error) {
+	m.recorder.record(m.id, "compensate")
+	return &TCCResp{ComponentID: m.id, TXID: txID, ACK: true}, nil
+}
+
+func Test_txManager_saga(t *testing.T) {
+	tests := []struct {
+		name       string
+		reject     bool
+		rejectShip bool
+		success    bool
+		calls      []string
+	}{
+		{
+			// saga 分支按照请求顺序依次执行，confirm 无需调用 saga 参与方
+			name:    "success",
+			success: true,
+			calls:   []string{"order:execute", "ship:execute", "inventory:try", "inventory:confirm"},
+		},
+		{
+			// 失败时按照执行顺序的逆序进行 cancel 与补偿
+			name:   "reject",
+			reject: true,
+			calls:  []string{"order:execute", "ship:execute", "inventory:try", "inventory:cancel", "ship:compensate", "order:compensate"},
+		},
+		{
+			// saga 分支失败时后续分支不再执行，但同样会收到 cancel
+			name:       "reject ship",
+			rejectShip: true,
+			calls:      []string{"order:execute", "ship:execute", "inventory:cancel", "ship:compensate", "order:compensate"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txManager := NewTXManager(newMockTXStore())
+			defer txManager.Stop()
+			var r recorder
+			assert.Equal(t, nil, txManager.Register(NewSagaComponent(&mockSagaComponent{id: "order", recorder: &r})))
+			assert.Equal(t, nil, txManager.Register(NewSagaComponent(&mockSagaComponent{id: "ship", recorder: &r})))
+			assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("inventory"), recorder: &r}))
+
+			result, err := txManager.Transaction(context.Background(), []*RequestEntity{
+				{ComponentID: "order"},
+				{ComponentID: "ship", Request: map[string]interface{}{"reject_flag": tt.rejectShip}},
+				// tcc 分支在 saga 分支全部执行成功后再 try
+				{ComponentID: "inventory", Request: map[string]interface{}{"reject_flag": tt.reject}, DependsOn: []string{"ship"}},
+			})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.calls, r.calls)
+
+			// saga 分支随事务日志持久化，轮询任务据此还原执行顺序
+			tx, err := txManager.GetTX(context.Background(), result.TXID)
+			assert.Equal(t, nil, err)
+			for _, component := range tx.Components {
+				assert.Equal(t, component.ComponentID != "inventory", component.Saga)
+			}
+		})
+	}
+}
+
+func Test_newBranchGraph_saga(t *testing.T) {
+	g, err := newBranchGraph([]branch{
+		{id: "b", saga: true},
+		{id: "tcc"},
+		{id: "a", saga: true},
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"b"}, g.deps["a"])
+	order, err := g.order()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"b", "tcc", "a"}, order)
+}
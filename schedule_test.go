@@ -0,0 +1,139 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_txManager_confirm_at(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		phase2    bool
+		advancing []string
+	}{
+		{
+			// confirm 时间未到时保持 hanging，到期后由轮询任务 confirm
+			name:      "delay",
+			delay:     20 * time.Millisecond,
+			advancing: []string{"a:try"},
+		},
+		{
+			// confirm 时间已过时立即 confirm
+			name:      "past",
+			delay:     -time.Second,
+			phase2:    true,
+			advancing: []string{"a:try", "a:confirm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+			defer txManager.Stop()
+			var r recorder
+			assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("a"), recorder: &r}))
+			ctx := context.Background()
+
+			result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithConfirmAt(time.Now().Add(tt.delay)))
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, result.Success)
+			assert.Equal(t, tt.phase2, result.Phase2Done)
+
+			txs, err := txManager.txStore.GetHangingTXs(ctx)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, nil, txManager.batchAdvanceProgress(txs))
+			assert.Equal(t, tt.advancing, r.calls)
+
+			<-time.After(tt.delay)
+			txs, err = txManager.txStore.GetHangingTXs(ctx)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, nil, txManager.batchAdvanceProgress(txs))
+			assert.Equal(t, []string{"a:try", "a:confirm"}, r.calls)
+			tx, err := txManager.GetTX(ctx, result.TXID)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, TXSuccessful, tx.Status)
+		})
+	}
+}
+
+func Test_txManager_hold_until(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var r recorder
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("a"), recorder: &r}))
+	ctx := context.Background()
+
+	// 外部条件在过期时间之前未满足，暂挂事务由轮询任务自动 cancel
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithHoldUntil(time.Now().Add(10*time.Millisecond)))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, TXHanging, result.Status)
+
+	<-time.After(20 * time.Millisecond)
+	assert.Equal(t, true, errors.Is(txManager.ResolveTX(ctx, result.TXID, true), ErrStoreConflict))
+	txs, err := txManager.txStore.GetHangingTXs(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, txManager.batchAdvanceProgress(txs))
+	assert.Equal(t, []string{"a:try", "a:cancel"}, r.calls)
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXFailure, tx.Status)
+
+	// 基础的 TXStore 不支持定时 confirm
+	baseManager := NewTXManager(&baseTXStore{TXStore: newMockTXStore()})
+	defer baseManager.Stop()
+	assert.Equal(t, nil, baseManager.Register(newMockComponent("a")))
+	_, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithConfirmAt(time.Now()))
+	assert.Equal(t, true, errors.Is(err, ErrStoreUnsupported))
+}
+
+func Test_txManager_hold_decision(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore, WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var r recorder
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("a"), recorder: &r}))
+	ctx := context.Background()
+	advance := func() {
+		txs, err := txStore.GetHangingTXs(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, txManager.batchAdvanceProgress(txs))
+	}
+
+	// confirm 的决议已经记录但第二阶段尚未执行，超过暂挂的过期时间后轮询任务仍然按照决议 confirm
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithHoldUntil(time.Now().Add(20*time.Millisecond)))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, txStore.(TXDecisionStore).TXDecide(ctx, result.TXID, true))
+	<-time.After(40 * time.Millisecond)
+	advance()
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXSuccessful, tx.Status)
+	assert.Equal(t, []string{"a:try", "a:confirm"}, r.calls)
+
+	// 轮询任务已经记录了过期 cancel 的决议，随后到达的 confirm 决议被拒绝
+	r.calls = nil
+	result, err = txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithHold())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, txStore.(TXDecisionStore).TXDecide(ctx, result.TXID, false))
+	assert.Equal(t, true, errors.Is(txManager.ResolveTX(ctx, result.TXID, true), ErrStoreConflict))
+	assert.Equal(t, []string{"a:try"}, r.calls)
+	// 重复 cancel 时按照已记录的决议完成第二阶段
+	assert.Equal(t, nil, txManager.ResolveTX(ctx, result.TXID, false))
+	tx, err = txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXFailure, tx.Status)
+	assert.Equal(t, []string{"a:try", "a:cancel"}, r.calls)
+
+	// 基础的 TXStore 不支持暂挂
+	baseManager := NewTXManager(&baseTXStore{TXStore: newMockTXStore()})
+	defer baseManager.Stop()
+	assert.Equal(t, nil, baseManager.Register(newMockComponent("a")))
+	_, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithHold())
+	assert.Equal(t, true, errors.Is(err, ErrStoreUnsupported))
+}
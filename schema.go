@@ -0,0 +1,85 @@
+package gotcc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// 组件请求参数的 Schema，注册组件时通过 WithSchema 指定.
+// 事务记录创建之前会基于 Schema 校验 RequestEntity.Request
+type Schema interface {
+	// Schema 的版本号，会随请求参数载荷一并持久化
+	Version() uint32
+	// 校验请求参数
+	Validate(request map[string]interface{}) error
+}
+
+// 字段类型
+type FieldType string
+
+const (
+	FieldAny    FieldType = "any"
+	FieldString FieldType = "string"
+	FieldNumber FieldType = "number"
+	FieldBool   FieldType = "bool"
+	FieldObject FieldType = "object"
+	FieldArray  FieldType = "array"
+)
+
+// 字段定义
+type SchemaField struct {
+	Name     string
+	Type     FieldType
+	Required bool
+}
+
+// 基于字段定义的 Schema. 未定义的字段不做校验
+type fieldSchema struct {
+	version uint32
+	fields  []SchemaField
+}
+
+func NewSchema(version uint32, fields ...SchemaField) Schema {
+	return &fieldSchema{
+		version: version,
+		fields:  fields,
+	}
+}
+
+func (f *fieldSchema) Version() uint32 {
+	return f.version
+}
+
+func (f *fieldSchema) Validate(request map[string]interface{}) error {
+	for _, field := range f.fields {
+		value, ok := request[field.Name]
+		if !ok || value == nil {
+			if field.Required {
+				return fmt.Errorf("field: %s is required", field.Name)
+			}
+			continue
+		}
+		if !field.Type.match(value) {
+			return fmt.Errorf("field: %s should be %s, got %T", field.Name, field.Type, value)
+		}
+	}
+	return nil
+}
+
+func (f FieldType) match(value interface{}) bool {
+	kind := reflect.TypeOf(value).Kind()
+	switch f {
+	case FieldString:
+		return kind == reflect.String
+	case FieldNumber:
+		return kind >= reflect.Int && kind <= reflect.Float64
+	case FieldBool:
+		return kind == reflect.Bool
+	case FieldObject:
+		return kind == reflect.Map || kind == reflect.Struct
+	case FieldArray:
+		return kind == reflect.Slice || kind == reflect.Array
+	default:
+		return true
+	}
+}
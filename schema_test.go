@@ -0,0 +1,58 @@
+package gotcc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Schema(t *testing.T) {
+	schema := NewSchema(1,
+		SchemaField{Name: "biz_id", Type: FieldString, Required: true},
+		SchemaField{Name: "amount", Type: FieldNumber},
+		SchemaField{Name: "tags", Type: FieldArray},
+	)
+
+	tests := []struct {
+		name      string
+		request   map[string]interface{}
+		expectErr bool
+	}{
+		{name: "valid", request: map[string]interface{}{"biz_id": "biz", "amount": 1, "tags": []interface{}{"a"}}},
+		{name: "optional", request: map[string]interface{}{"biz_id": "biz", "other": true}},
+		{name: "missing", request: map[string]interface{}{"amount": 1.5}, expectErr: true},
+		{name: "type", request: map[string]interface{}{"biz_id": "biz", "amount": "1"}, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectErr, schema.Validate(tt.request) != nil)
+		})
+	}
+}
+
+func Test_txManager_schema_payload(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore)
+	defer txManager.Stop()
+	assert.Equal(t, nil, txManager.Register(newMockComponent("a"), WithSchema(NewSchema(3, SchemaField{Name: "biz_id", Type: FieldString, Required: true}))))
+	ctx := context.Background()
+
+	// 不满足 Schema 的请求在创建事务记录之前被拒绝
+	_, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a", Request: map[string]interface{}{"biz_id": 1}}})
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 0, len(txStore.(*mockTXStore).txs))
+
+	// 请求参数以 Schema 的版本号持久化到事务日志中
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a", Request: map[string]interface{}{"biz_id": "biz"}}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	payload := tx.Components[0].Request
+	assert.Equal(t, JSONCodec{}.Name(), payload.Codec)
+	assert.Equal(t, uint32(3), payload.Version)
+	var request map[string]interface{}
+	assert.Equal(t, nil, payload.Decode(&request))
+	assert.Equal(t, map[string]interface{}{"biz_id": "biz"}, request)
+}
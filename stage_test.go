@@ -0,0 +1,106 @@
+package gotcc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 记录各组件各阶段的调用顺序
+type recorder struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(componentID string, phase Phase) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls = append(r.calls, componentID+":"+phase.String())
+}
+
+type recordComponent struct {
+	TCCComponent
+	recorder *recorder
+}
+
+func (r *recordComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	r.recorder.record(r.ID(), PhaseTry)
+	return r.TCCComponent.Try(ctx, req)
+}
+
+func (r *recordComponent) Confirm(ctx context.Context, txID string) (*TCCResp, error) {
+	r.recorder.record(r.ID(), PhaseConfirm)
+	return r.TCCComponent.Confirm(ctx, txID)
+}
+
+func (r *recordComponent) Cancel(ctx context.Context, txID string) (*TCCResp, error) {
+	r.recorder.record(r.ID(), PhaseCancel)
+	return r.TCCComponent.Cancel(ctx, txID)
+}
+
+func Test_txManager_stage(t *testing.T) {
+	tests := []struct {
+		name    string
+		reject  bool
+		success bool
+		calls   []string
+	}{
+		{
+			name:    "success",
+			success: true,
+			calls:   []string{"inventory:try", "charge:try", "inventory:confirm", "charge:confirm"},
+		},
+		{
+			// 前序阶段失败时后续阶段不再执行 try，cancel 按照阶段逆序执行
+			name:   "reject",
+			reject: true,
+			calls:  []string{"inventory:try", "charge:cancel", "inventory:cancel"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txManager := NewTXManager(newMockTXStore())
+			defer txManager.Stop()
+			var r recorder
+			assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("inventory"), recorder: &r}))
+			assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: newMockComponent("charge"), recorder: &r}))
+
+			result, err := txManager.Transaction(context.Background(), []*RequestEntity{
+				{ComponentID: "charge", Stage: 1},
+				{ComponentID: "inventory", Request: map[string]interface{}{"reject_flag": tt.reject}},
+			})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.calls, r.calls)
+		})
+	}
+}
+
+func Test_txManager_stage_deadline(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore(), WithMonitorTick(10*time.Millisecond))
+	defer txManager.Stop()
+	var r recorder
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: &sleepComponent{TCCComponent: newMockComponent("a"), sleep: 80 * time.Millisecond}, recorder: &r}))
+	assert.Equal(t, nil, txManager.Register(&recordComponent{TCCComponent: &sleepComponent{TCCComponent: newMockComponent("b"), sleep: 80 * time.Millisecond}, recorder: &r}))
+	assert.Equal(t, nil, txManager.Register(newMockComponent("c")))
+	ctx := context.Background()
+
+	// 两个阶段的总耗时超过任意一个组件的超时时长，事务的截止时间需要覆盖整条链路
+	result, err := txManager.Transaction(ctx, []*RequestEntity{
+		{ComponentID: "a", Timeout: 100 * time.Millisecond},
+		{ComponentID: "b", Stage: 1, Timeout: 100 * time.Millisecond},
+		{ComponentID: "c", Timeout: 150 * time.Millisecond},
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, []string{"a:try", "b:try", "a:confirm", "b:confirm"}, r.calls)
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXSuccessful, tx.Status)
+	// 第 0 阶段中耗时最长的 c 加上第 1 阶段的 b
+	assert.Equal(t, 250*time.Millisecond, tx.Deadline.Sub(tx.CreatedAt))
+}
@@ -1,45 +1,369 @@
 package gotcc
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
+type RegisterOptions struct {
+	// 组件版本号，替换组件时新版本号必须大于当前版本号
+	Version uint64
+	// 组件的访问地址. 配置了 Registry 时会发布到 Registry 上，供其他节点访问
+	Endpoint string
+	// 请求参数的 Schema，创建事务记录之前据此校验请求参数
+	Schema Schema
+	// 各阶段请求的超时时长，未指定时使用 TXManager 的 Timeout
+	Timeouts map[Phase]time.Duration
+}
+
+type RegisterOption func(*RegisterOptions)
+
+func WithVersion(version uint64) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Version = version
+	}
+}
+
+func WithEndpoint(endpoint string) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Endpoint = endpoint
+	}
+}
+
+func WithSchema(schema Schema) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.Schema = schema
+	}
+}
+
+func WithTryTimeout(timeout time.Duration) RegisterOption {
+	return withPhaseTimeout(PhaseTry, timeout)
+}
+
+func WithConfirmTimeout(timeout time.Duration) RegisterOption {
+	return withPhaseTimeout(PhaseConfirm, timeout)
+}
+
+func WithCancelTimeout(timeout time.Duration) RegisterOption {
+	return withPhaseTimeout(PhaseCancel, timeout)
+}
+
+func withPhaseTimeout(phase Phase, timeout time.Duration) RegisterOption {
+	return func(o *RegisterOptions) {
+		if o.Timeouts == nil {
+			o.Timeouts = make(map[Phase]time.Duration)
+		}
+		o.Timeouts[phase] = timeout
+	}
+}
+
 type registryCenter struct {
 	mux        sync.RWMutex
-	components map[string]TCCComponent
+	components map[string]*registeredComponent
+	// 各组件正在参与的、尚未执行完成的本地事务数量. 注销组件时需要等待其归零，
+	// 避免事务在获取组件之后、创建事务记录之前被漏检
+	inflight map[string]int
+	// 等待组件的在途事务归零的注销流程
+	idle map[string]chan struct{}
+	// 本地未注册的组件，通过 registry 查询地址后由 dialer 构造
+	registry Registry
+	dialer   Dialer
 }
 
-func newRegistryCenter() *registryCenter {
+// 注册的组件及其版本信息
+type registeredComponent struct {
+	component TCCComponent
+	version   uint64
+	// 注销中的组件不再参与新的事务，但仍可以推进存量事务
+	draining bool
+	// 已发布到 registry 上的访问地址
+	endpoint string
+	// 是否是通过 registry 查询得到的远端组件
+	remote bool
+	// 请求参数的 Schema
+	schema Schema
+	// 各阶段请求的超时时长
+	timeouts map[Phase]time.Duration
+}
+
+func newRegistryCenter(registry Registry, dialer Dialer) *registryCenter {
 	return &registryCenter{
-		components: make(map[string]TCCComponent),
+		components: make(map[string]*registeredComponent),
+		inflight:   make(map[string]int),
+		idle:       make(map[string]chan struct{}),
+		registry:   registry,
+		dialer:     dialer,
 	}
 }
 
-func (r *registryCenter) register(component TCCComponent) error {
+func (r *registryCenter) register(ctx context.Context, component TCCComponent, opts ...RegisterOption) error {
+	var o RegisterOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r.mux.Lock()
+	if registered, ok := r.components[component.ID()]; ok && !registered.remote {
+		r.mux.Unlock()
+		return componentError(component.ID(), "", ErrDuplicateComponent)
+	}
+	r.components[component.ID()] = &registeredComponent{
+		component: component,
+		version:   o.Version,
+		schema:    o.Schema,
+		timeouts:  o.Timeouts,
+	}
+	r.mux.Unlock()
+
+	if err := r.publish(ctx, component.ID(), o); err != nil {
+		r.unregister(component.ID())
+		return err
+	}
+	return nil
+}
+
+// 替换已注册的组件. 未指定版本号时，版本号在当前版本的基础上递增
+func (r *registryCenter) replace(ctx context.Context, component TCCComponent, opts ...RegisterOption) error {
+	var o RegisterOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r.mux.Lock()
+	registered, ok := r.components[component.ID()]
+	if !ok || registered.remote {
+		r.mux.Unlock()
+		return componentError(component.ID(), "", ErrComponentNotFound)
+	}
+	if registered.draining {
+		r.mux.Unlock()
+		return componentError(component.ID(), "", ErrComponentUnregistering)
+	}
+	if o.Version == 0 {
+		o.Version = registered.version + 1
+	}
+	if o.Version <= registered.version {
+		r.mux.Unlock()
+		return componentError(component.ID(), "", fmt.Errorf("%w, version: %d, current version: %d", ErrStaleVersion, o.Version, registered.version))
+	}
+	r.components[component.ID()] = &registeredComponent{
+		component: component,
+		version:   o.Version,
+		endpoint:  registered.endpoint,
+		schema:    o.Schema,
+		timeouts:  o.Timeouts,
+	}
+	r.mux.Unlock()
+
+	return r.publish(ctx, component.ID(), o)
+}
+
+// 把组件的访问地址发布到 registry 上
+func (r *registryCenter) publish(ctx context.Context, componentID string, o RegisterOptions) error {
+	if r.registry == nil || o.Endpoint == "" {
+		return nil
+	}
+	if err := r.registry.Register(ctx, &ComponentEndpoint{
+		ComponentID: componentID,
+		Endpoint:    o.Endpoint,
+		Version:     o.Version,
+	}); err != nil {
+		return err
+	}
+
 	r.mux.Lock()
 	defer r.mux.Unlock()
-	if _, ok := r.components[component.ID()]; ok {
-		return errors.New("repeat component id")
+	if registered, ok := r.components[componentID]; ok {
+		registered.endpoint = o.Endpoint
 	}
-	r.components[component.ID()] = component
 	return nil
 }
 
-func (r *registryCenter) getComponents(componentIDs ...string) ([]TCCComponent, error) {
-	components := make([]TCCComponent, 0, len(componentIDs))
+// 把组件置为注销中，避免在检查存量事务期间有新的事务使用该组件
+func (r *registryCenter) drain(componentID string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	registered, ok := r.components[componentID]
+	if !ok || registered.remote {
+		return componentError(componentID, "", ErrComponentNotFound)
+	}
+	if registered.draining {
+		return componentError(componentID, "", ErrComponentUnregistering)
+	}
+	registered.draining = true
+	return nil
+}
+
+// 等待组件的在途事务全部执行完成，直到 ctx 结束
+func (r *registryCenter) await(ctx context.Context, componentID string) error {
+	r.mux.Lock()
+	if r.inflight[componentID] == 0 {
+		r.mux.Unlock()
+		return nil
+	}
+	ch, ok := r.idle[componentID]
+	if !ok {
+		ch = make(chan struct{})
+		r.idle[componentID] = ch
+	}
+	r.mux.Unlock()
 
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 释放组件的在途事务计数，归零时唤醒等待中的注销流程
+func (r *registryCenter) release(componentIDs ...string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, componentID := range componentIDs {
+		if r.inflight[componentID]--; r.inflight[componentID] > 0 {
+			continue
+		}
+		delete(r.inflight, componentID)
+		if ch, ok := r.idle[componentID]; ok {
+			close(ch)
+			delete(r.idle, componentID)
+		}
+	}
+}
+
+// 撤销注销中的状态
+func (r *registryCenter) undrain(componentID string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if registered, ok := r.components[componentID]; ok {
+		registered.draining = false
+	}
+}
+
+// 仅移除本地的注册信息
+func (r *registryCenter) unregister(componentID string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.components, componentID)
+}
+
+// 注销组件，已发布到 registry 上的访问地址一并注销
+func (r *registryCenter) deregister(ctx context.Context, componentID string) error {
+	r.mux.Lock()
+	registered, ok := r.components[componentID]
+	delete(r.components, componentID)
+	r.mux.Unlock()
+
+	if !ok || registered.endpoint == "" || r.registry == nil {
+		return nil
+	}
+	return r.registry.Deregister(ctx, componentID)
+}
+
+func (r *registryCenter) version(componentID string) (uint64, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	registered, ok := r.components[componentID]
+	if !ok || registered.remote {
+		return 0, componentError(componentID, "", ErrComponentNotFound)
+	}
+	return registered.version, nil
+}
+
+// 获取组件请求参数的 Schema，未指定时返回 nil
+func (r *registryCenter) schema(componentID string) Schema {
 	r.mux.RLock()
 	defer r.mux.RUnlock()
+	if registered, ok := r.components[componentID]; ok {
+		return registered.schema
+	}
+	return nil
+}
 
+// 获取组件指定阶段请求的超时时长，未指定时返回 0
+func (r *registryCenter) timeout(componentID string, phase Phase) time.Duration {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	if registered, ok := r.components[componentID]; ok {
+		return registered.timeouts[phase]
+	}
+	return 0
+}
+
+// 获取参与新事务的组件，注销中的组件视为不存在. 获取成功时各组件的在途事务计数加一，
+// 调用方需要在事务执行完成后通过返回的 release 释放
+func (r *registryCenter) getComponents(ctx context.Context, componentIDs ...string) ([]TCCComponent, func(), error) {
+	components := make([]TCCComponent, 0, len(componentIDs))
+	acquired := make([]string, 0, len(componentIDs))
 	for _, componentID := range componentIDs {
-		component, ok := r.components[componentID]
-		if !ok {
-			return nil, fmt.Errorf("component id: %s not existed", componentID)
+		// 检查注销状态与计数在同一把锁内完成，保证注销流程能够观察到计数
+		r.mux.Lock()
+		registered, ok := r.components[componentID]
+		if ok && registered.draining {
+			r.mux.Unlock()
+			r.release(acquired...)
+			return nil, nil, componentError(componentID, "", ErrComponentNotFound)
+		}
+		r.inflight[componentID]++
+		r.mux.Unlock()
+		acquired = append(acquired, componentID)
+
+		component, err := r.resolve(ctx, componentID, registered)
+		if err != nil {
+			r.release(acquired...)
+			return nil, nil, err
 		}
 		components = append(components, component)
 	}
 
-	return components, nil
+	var once sync.Once
+	return components, func() { once.Do(func() { r.release(acquired...) }) }, nil
+}
+
+// 获取推进存量事务的组件，注销中的组件仍然可用
+func (r *registryCenter) getComponent(ctx context.Context, componentID string) (TCCComponent, error) {
+	r.mux.RLock()
+	registered := r.components[componentID]
+	r.mux.RUnlock()
+	return r.resolve(ctx, componentID, registered)
+}
+
+// 优先使用本地注册的组件，其次通过 registry 查询远端组件. 远端组件的地址或版本变化时重新构造
+func (r *registryCenter) resolve(ctx context.Context, componentID string, registered *registeredComponent) (TCCComponent, error) {
+	if registered != nil && !registered.remote {
+		return registered.component, nil
+	}
+	if r.registry == nil || r.dialer == nil {
+		return nil, componentError(componentID, "", ErrComponentNotFound)
+	}
+
+	endpoint, err := r.registry.Lookup(ctx, componentID)
+	if err != nil {
+		return nil, err
+	}
+	if registered != nil && registered.endpoint == endpoint.Endpoint && registered.version == endpoint.Version {
+		return registered.component, nil
+	}
+
+	component, err := r.dialer(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	// 查询期间本地注册了同名组件时，以本地组件为准
+	if current, ok := r.components[componentID]; ok && !current.remote {
+		return current.component, nil
+	}
+	r.components[componentID] = &registeredComponent{
+		component: component,
+		version:   endpoint.Version,
+		endpoint:  endpoint.Endpoint,
+		remote:    true,
+	}
+	return component, nil
 }
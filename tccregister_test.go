@@ -0,0 +1,111 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_txManager_register(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore)
+	defer txManager.Stop()
+	ctx := context.Background()
+
+	assert.Equal(t, nil, txManager.Register(newMockComponent("a"), WithVersion(1)))
+	assert.NotEqual(t, nil, txManager.Register(newMockComponent("a")))
+
+	// 替换组件，版本号需要递增
+	assert.Equal(t, nil, txManager.Replace(newMockComponent("a")))
+	version, err := txManager.ComponentVersion("a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(2), version)
+	assert.NotEqual(t, nil, txManager.Replace(newMockComponent("a"), WithVersion(2)))
+	assert.Equal(t, nil, txManager.Replace(newMockComponent("a"), WithVersion(5)))
+	assert.NotEqual(t, nil, txManager.Replace(newMockComponent("b")))
+
+	// 存在未完成的事务时，拒绝注销
+	txID, err := txStore.CreateTX(ctx, newMockComponent("a"))
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, txManager.Unregister(ctx, "a"))
+	_, release, err := txManager.getComponents(ctx, 0, &RequestEntity{ComponentID: "a"})
+	assert.Equal(t, nil, err)
+	release()
+
+	// 事务完成后允许注销，注销后的组件不再参与新的事务
+	assert.Equal(t, nil, txStore.TXSubmit(ctx, txID, false))
+	assert.Equal(t, nil, txManager.Unregister(ctx, "a"))
+	_, _, err = txManager.getComponents(ctx, 0, &RequestEntity{ComponentID: "a"})
+	assert.NotEqual(t, nil, err)
+	assert.NotEqual(t, nil, txManager.Unregister(ctx, "a"))
+	assert.Equal(t, nil, txManager.Register(newMockComponent("a")))
+}
+
+func Test_registryCenter_draining(t *testing.T) {
+	r := newRegistryCenter(nil, nil)
+	assert.Equal(t, nil, r.register(context.Background(), newMockComponent("a")))
+	assert.Equal(t, nil, r.drain("a"))
+
+	// 注销中的组件不能参与新的事务，但仍可推进存量事务
+	_, _, err := r.getComponents(context.Background(), "a")
+	assert.NotEqual(t, nil, err)
+	component, err := r.getComponent(context.Background(), "a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a", component.ID())
+	assert.NotEqual(t, nil, r.replace(context.Background(), newMockComponent("a")))
+
+	r.undrain("a")
+	_, release, err := r.getComponents(context.Background(), "a")
+	assert.Equal(t, nil, err)
+	release()
+}
+
+func Test_txManager_Unregister_inflight(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore(), WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	ctx := context.Background()
+	assert.Equal(t, nil, txManager.Register(newMockComponent("a")))
+
+	// 已获取组件、尚未创建事务记录的事务执行期间，注销流程等待其完成
+	_, release, err := txManager.getComponents(ctx, 0, &RequestEntity{ComponentID: "a"})
+	assert.Equal(t, nil, err)
+	tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, true, errors.Is(txManager.Unregister(tctx, "a"), ErrComponentBusy))
+	// 等待超时后撤销注销，组件仍可参与新的事务
+	_, again, err := txManager.getComponents(ctx, 0, &RequestEntity{ComponentID: "a"})
+	assert.Equal(t, nil, err)
+	again()
+
+	unregistered := make(chan error, 1)
+	go func() {
+		unregistered <- txManager.Unregister(ctx, "a")
+	}()
+	select {
+	case <-unregistered:
+		t.Fatal("unregister before inflight tx finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release()
+	assert.Equal(t, nil, <-unregistered)
+
+	// 并发的事务与注销之间，事务要么在注销前完成，要么无法获取组件
+	assert.Equal(t, nil, txManager.Register(newMockComponent("a")))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}); err == nil {
+				assert.Equal(t, true, result.Success)
+				assert.Equal(t, true, result.Phase2Done)
+			}
+		}()
+	}
+	assert.Equal(t, nil, txManager.Unregister(ctx, "a"))
+	wg.Wait()
+}
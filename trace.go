@@ -0,0 +1,89 @@
+package gotcc
+
+import "context"
+
+// 链路追踪模块
+type Tracer interface {
+	// 开启一个 span，返回携带该 span 的 ctx
+	Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, Span)
+	// 将 ctx 中的链路信息注入到 carrier 当中，用于跨进程传递
+	Inject(ctx context.Context, carrier map[string]string)
+	// 从 carrier 当中提取出链路信息，并注入到 ctx 当中
+	Extract(ctx context.Context, carrier map[string]string) context.Context
+}
+
+// 链路中的一个 span
+type Span interface {
+	// 设置 span 属性
+	SetAttribute(key, value string)
+	// 记录 span 执行过程中遇到的错误
+	RecordError(err error)
+	// 结束 span
+	End()
+}
+
+// 开启 span 时的配置项
+type SpanConfig struct {
+	// span 属性
+	Attributes map[string]string
+	// 关联的其他链路，以 carrier 的形式给出
+	Links []map[string]string
+}
+
+type SpanOption func(*SpanConfig)
+
+func WithSpanAttribute(key, value string) SpanOption {
+	return func(c *SpanConfig) {
+		if c.Attributes == nil {
+			c.Attributes = make(map[string]string)
+		}
+		c.Attributes[key] = value
+	}
+}
+
+func WithSpanLink(carrier map[string]string) SpanOption {
+	return func(c *SpanConfig) {
+		if len(carrier) == 0 {
+			return
+		}
+		c.Links = append(c.Links, carrier)
+	}
+}
+
+// 供 Tracer 的实现方解析 span 配置项
+func NewSpanConfig(opts ...SpanOption) *SpanConfig {
+	var c SpanConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// span 属性 key
+const (
+	SpanAttrTXID        = "gotcc.tx_id"
+	SpanAttrComponentID = "gotcc.component_id"
+	SpanAttrPhase       = "gotcc.phase"
+	SpanAttrACK         = "gotcc.ack"
+	// 事务标签的属性前缀
+	SpanAttrLabelPrefix = "gotcc.label."
+)
+
+// 默认的空实现，不记录任何链路信息
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+func (nopTracer) Inject(ctx context.Context, carrier map[string]string) {}
+
+func (nopTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return ctx
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(key, value string) {}
+func (nopSpan) RecordError(err error)          {}
+func (nopSpan) End()                           {}
@@ -0,0 +1,184 @@
+package gotcc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSpanCtxKey struct{}
+
+type mockSpan struct {
+	tracer   *mockTracer
+	id       string
+	name     string
+	parentID string
+	links    []map[string]string
+	attrs    map[string]string
+	errs     []error
+}
+
+func (m *mockSpan) SetAttribute(key, value string) {
+	m.tracer.mutex.Lock()
+	defer m.tracer.mutex.Unlock()
+	m.attrs[key] = value
+}
+
+func (m *mockSpan) RecordError(err error) {
+	m.tracer.mutex.Lock()
+	defer m.tracer.mutex.Unlock()
+	m.errs = append(m.errs, err)
+}
+
+func (m *mockSpan) End() {}
+
+type mockTracer struct {
+	mutex sync.Mutex
+	spans []*mockSpan
+}
+
+func (m *mockTracer) Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, Span) {
+	conf := NewSpanConfig(opts...)
+	parentID, _ := ctx.Value(mockSpanCtxKey{}).(string)
+	span := mockSpan{
+		tracer:   m,
+		id:       uuid.NewString(),
+		name:     name,
+		parentID: parentID,
+		links:    conf.Links,
+		attrs:    make(map[string]string),
+	}
+	for k, v := range conf.Attributes {
+		span.attrs[k] = v
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.spans = append(m.spans, &span)
+	return context.WithValue(ctx, mockSpanCtxKey{}, span.id), &span
+}
+
+func (m *mockTracer) Inject(ctx context.Context, carrier map[string]string) {
+	if spanID, _ := ctx.Value(mockSpanCtxKey{}).(string); spanID != "" {
+		carrier["span"] = spanID
+	}
+}
+
+func (m *mockTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if spanID := carrier["span"]; spanID != "" {
+		return context.WithValue(ctx, mockSpanCtxKey{}, spanID)
+	}
+	return ctx
+}
+
+func (m *mockTracer) getSpans(name string) []*mockSpan {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var spans []*mockSpan
+	for _, span := range m.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+type metadataComponent struct {
+	TCCComponent
+	mutex    sync.Mutex
+	metadata map[string]string
+}
+
+func (m *metadataComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	m.mutex.Lock()
+	m.metadata = req.Metadata
+	m.mutex.Unlock()
+	return m.TCCComponent.Try(ctx, req)
+}
+
+func Test_txmanager_trace(t *testing.T) {
+	tracer := mockTracer{}
+	txmanager := NewTXManager(newMockTXStore(), WithTracer(&tracer))
+	defer txmanager.Stop()
+
+	component := metadataComponent{TCCComponent: newMockComponent("a")}
+	if err := txmanager.Register(&component); err != nil {
+		t.Error(err)
+		return
+	}
+
+	ctx := context.Background()
+	result, err := txmanager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, true, result.Success)
+
+	txSpans := tracer.getSpans("gotcc.transaction")
+	trySpans := tracer.getSpans("gotcc.try")
+	confirmSpans := tracer.getSpans("gotcc.confirm")
+	if !assert.Equal(t, 1, len(txSpans)) || !assert.Equal(t, 1, len(trySpans)) || !assert.Equal(t, 1, len(confirmSpans)) {
+		return
+	}
+	assert.Equal(t, result.TXID, txSpans[0].attrs[SpanAttrTXID])
+	assert.Equal(t, txSpans[0].id, trySpans[0].parentID)
+	assert.Equal(t, txSpans[0].id, confirmSpans[0].parentID)
+	assert.Equal(t, "a", trySpans[0].attrs[SpanAttrComponentID])
+	assert.Equal(t, "true", trySpans[0].attrs[SpanAttrACK])
+	// try 请求的元数据中携带了 try span 的链路信息
+	assert.Equal(t, trySpans[0].id, component.metadata["span"])
+
+	// 事务明细中记录了开启事务时的链路信息
+	tx, err := txmanager.txStore.GetTX(ctx, result.TXID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, txSpans[0].id, tx.Metadata["span"])
+}
+
+func Test_txmanager_trace_recover(t *testing.T) {
+	tracer := mockTracer{}
+	txStore := newMockTXStore()
+	txmanager := NewTXManager(txStore, WithTracer(&tracer), WithMonitorTick(100*time.Millisecond))
+	defer txmanager.Stop()
+
+	if err := txmanager.Register(newMockComponent("a")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// 模拟一笔 try 失败，但第二阶段未执行的事务
+	ctx := context.Background()
+	txID, err := txStore.(TXDetailStore).CreateTXDetail(ctx, &Transaction{
+		Status:    TXHanging,
+		CreatedAt: time.Now(),
+		Components: []*ComponentTryEntity{
+			{ComponentID: "a", TryStatus: TryFailure},
+		},
+		Metadata: map[string]string{"span": "origin"},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	<-time.After(500 * time.Millisecond)
+	recoverSpans := tracer.getSpans("gotcc.recover")
+	if !assert.Equal(t, true, len(recoverSpans) > 0) {
+		return
+	}
+	assert.Equal(t, txID, recoverSpans[0].attrs[SpanAttrTXID])
+	assert.Equal(t, []map[string]string{{"span": "origin"}}, recoverSpans[0].links)
+
+	cancelSpans := tracer.getSpans("gotcc.cancel")
+	if !assert.Equal(t, 1, len(cancelSpans)) {
+		return
+	}
+	assert.Equal(t, recoverSpans[0].id, cancelSpans[0].parentID)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -19,16 +21,18 @@ type TXManager struct {
 	opts           *Options
 	txStore        TXStore
 	registryCenter *registryCenter
+	// 本节点上开启的、存在异步 try 的事务中各组件的请求
+	pendingTries *pendingTries
 }
 
 func NewTXManager(txStore TXStore, opts ...Option) *TXManager {
 	ctx, cancel := context.WithCancel(context.Background())
 	txManager := TXManager{
-		opts:           &Options{},
-		txStore:        txStore,
-		registryCenter: newRegistryCenter(),
-		ctx:            ctx,
-		stop:           cancel,
+		opts:         &Options{},
+		txStore:      txStore,
+		ctx:          ctx,
+		stop:         cancel,
+		pendingTries: newPendingTries(),
 	}
 
 	for _, opt := range opts {
@@ -36,6 +40,7 @@ func NewTXManager(txStore TXStore, opts ...Option) *TXManager {
 	}
 
 	repair(txManager.opts)
+	txManager.registryCenter = newRegistryCenter(txManager.opts.Registry, txManager.opts.Dialer)
 
 	go txManager.run()
 	return &txManager
@@ -45,29 +50,221 @@ func (t *TXManager) Stop() {
 	t.stop()
 }
 
-func (t *TXManager) Register(component TCCComponent) error {
-	return t.registryCenter.register(component)
+func (t *TXManager) Register(component TCCComponent, opts ...RegisterOption) error {
+	return t.registryCenter.register(t.ctx, component, opts...)
 }
 
-// 事务
-func (t *TXManager) Transaction(ctx context.Context, reqs ...*RequestEntity) (string, bool, error) {
+// 替换已注册的组件，例如参与方的地址在发布期间发生了变化. 存量事务后续会使用新的组件推进
+func (t *TXManager) Replace(component TCCComponent, opts ...RegisterOption) error {
+	return t.registryCenter.replace(t.ctx, component, opts...)
+}
+
+// 注销组件. 等待本地正在使用该组件的事务执行完成，直到 ctx 结束；仍有未完成的事务使用该组件时，拒绝注销
+func (t *TXManager) Unregister(ctx context.Context, componentID string) error {
+	// 先置为注销中，使得新的事务无法再使用该组件
+	if err := t.registryCenter.drain(componentID); err != nil {
+		return err
+	}
+	// 已获取组件但尚未创建事务记录的事务不会出现在事务日志中，需要等待其执行完成
+	if err := t.registryCenter.await(ctx, componentID); err != nil {
+		t.registryCenter.undrain(componentID)
+		return componentError(componentID, "", fmt.Errorf("%w: %w", ErrComponentBusy, err))
+	}
+
+	txs, err := t.txStore.GetHangingTXs(ctx)
+	if err != nil {
+		t.registryCenter.undrain(componentID)
+		return err
+	}
+	for _, tx := range txs {
+		for _, component := range tx.Components {
+			if component.ComponentID == componentID {
+				t.registryCenter.undrain(componentID)
+				return componentError(componentID, "", fmt.Errorf("%w: %s", ErrComponentBusy, tx.TXID))
+			}
+		}
+	}
+
+	return t.registryCenter.deregister(ctx, componentID)
+}
+
+// 获取已注册组件的版本号
+func (t *TXManager) ComponentVersion(componentID string) (uint64, error) {
+	return t.registryCenter.version(componentID)
+}
+
+// 查询一笔事务的执行进度
+func (t *TXManager) GetTX(ctx context.Context, txID string) (*Transaction, error) {
+	return t.txStore.GetTX(ctx, txID)
+}
+
+// 事务. 可通过 opts 指定单笔事务的参数.
+// 返回事务的执行结果，其中包含各组件 try 请求的执行情况，以及第二阶段是否已在本次调用内执行完成.
+// 事务记录创建之前出现的错误通过 error 返回，此时执行结果为 nil
+func (t *TXManager) Transaction(ctx context.Context, reqs []*RequestEntity, opts ...TXOption) (*TXResult, error) {
+	startedAt := time.Now()
+	var txOpts TXOptions
+	for _, opt := range opts {
+		opt(&txOpts)
+	}
+
+	ctx, span := t.opts.Tracer.Start(ctx, "gotcc.transaction", labelSpanOptions(txOpts.Labels)...)
+	defer span.End()
+
 	tctx, cancel := context.WithTimeout(ctx, t.opts.Timeout)
 	defer cancel()
 
-	// 获得所有的组件
-	componentEntities, err := t.getComponents(tctx, reqs...)
+	// 获得所有的组件，事务执行完成之前组件不能被注销
+	componentEntities, release, err := t.getComponents(tctx, txOpts.Timeout, reqs...)
 	if err != nil {
-		return "", false, err
+		span.RecordError(err)
+		return nil, err
+	}
+	defer release()
+
+	// 携带幂等键的重复请求，直接复用已有事务的结果
+	if txOpts.IdempotencyKey != "" {
+		tx, err := t.getTXByIdempotencyKey(tctx, txOpts.IdempotencyKey)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if tx != nil {
+			span.SetAttribute(SpanAttrTXID, tx.TXID)
+			return t.awaitTX(ctx, tx)
+		}
 	}
 
 	// 1 先创建事务明细记录，并取得全局唯一的事务 id
-	txID, err := t.txStore.CreateTX(tctx, componentEntities.ToComponents()...)
+	txID, err := t.createTX(tctx, componentEntities, &txOpts)
+	if errors.Is(err, ErrDuplicateIdempotencyKey) {
+		// 并发的重复请求抢先创建了事务
+		var tx *Transaction
+		if tx, err = t.getTXByIdempotencyKey(tctx, txOpts.IdempotencyKey); err == nil && tx != nil {
+			span.SetAttribute(SpanAttrTXID, tx.TXID)
+			return t.awaitTX(ctx, tx)
+		}
+	}
 	if err != nil {
-		return "", false, err
+		span.RecordError(err)
+		return nil, err
 	}
+	span.SetAttribute(SpanAttrTXID, txID)
+	ctx = log.WithTXID(ctx, txID)
+	t.opts.Metrics.TXStarted()
 
 	// 2. 两阶段提交， try-confirm/cancel
-	return txID, t.twoPhaseCommit(ctx, txID, componentEntities), nil
+	return t.twoPhaseCommit(ctx, txID, componentEntities, startedAt), nil
+}
+
+// 创建事务明细记录. txStore 支持持久化事务明细时，会把当前的链路信息以及各组件的请求参数一并记录，
+// 便于轮询任务关联到原始链路
+func (t *TXManager) createTX(ctx context.Context, componentEntities ComponentEntities, txOpts *TXOptions) (string, error) {
+	if _, ok := t.txStore.(TXApprovalStore); txOpts.ApprovalTimeout > 0 && !ok {
+		return "", fmt.Errorf("%w: txStore does not implement TXApprovalStore, approval is not supported", ErrStoreUnsupported)
+	}
+	if _, ok := t.txStore.(TXDecisionStore); (txOpts.ApprovalTimeout > 0 || txOpts.Hold) && !ok {
+		return "", fmt.Errorf("%w: txStore does not implement TXDecisionStore, approval and hold are not supported", ErrStoreUnsupported)
+	}
+	detailStore, ok := t.txStore.(TXDetailStore)
+	if !ok {
+		// 单笔事务的参数均依赖于 txStore 的持久化能力，轮询任务推进事务时同样需要遵循.
+		// 无法持久化时直接报错，避免例如重试策略、超时时长等参数被静默丢弃
+		if txOpts.persistent() {
+			return "", fmt.Errorf("%w: txStore does not implement TXDetailStore, tx options are not supported", ErrStoreUnsupported)
+		}
+		// 执行阶段、依赖关系以及 saga 分支同理，轮询任务无从得知时会按照默认的顺序推进事务
+		if componentEntities.persistent() {
+			return "", fmt.Errorf("%w: txStore does not implement TXDetailStore, stage, dependency and saga are not supported", ErrStoreUnsupported)
+		}
+		// 无法持久化截止时间时，轮询任务以 CreatedAt 加上 TXManager 的 Timeout 作为截止时间，
+		// try 超时时长更长时可能在 try 仍在执行时被判定为超时而 cancel
+		if timeout := componentEntities.criticalTimeout(); timeout > t.opts.Timeout {
+			return "", fmt.Errorf("%w: txStore does not implement TXDetailStore, try timeout %s exceeds tx timeout %s", ErrStoreUnsupported, timeout, t.opts.Timeout)
+		}
+		return t.txStore.CreateTX(ctx, componentEntities.ToComponents()...)
+	}
+
+	now := time.Now()
+	tx := Transaction{
+		TXID:       txOpts.TXID,
+		Status:     TXHanging,
+		CreatedAt:  now,
+		Components: make([]*ComponentTryEntity, 0, len(componentEntities)),
+		Metadata:   make(map[string]string),
+		// 以依赖关系上耗时最长的一条 try 链路作为事务的截止时间，分阶段执行的组件超时时长累加
+		Deadline:        now.Add(componentEntities.criticalTimeout()),
+		Labels:          txOpts.Labels,
+		IdempotencyKey:  txOpts.IdempotencyKey,
+		RetryPolicy:     txOpts.RetryPolicy,
+		Priority:        txOpts.Priority,
+		Hold:            txOpts.Hold,
+		ApprovalTimeout: txOpts.ApprovalTimeout,
+		ConfirmAt:       txOpts.ConfirmAt,
+		HoldExpireAt:    txOpts.HoldExpireAt,
+	}
+	for _, componentEntity := range componentEntities {
+		// 请求参数以 Schema 版本号作为载荷的版本号
+		var version uint32
+		if schema := t.registryCenter.schema(componentEntity.Component.ID()); schema != nil {
+			version = schema.Version()
+		}
+		request, err := EncodePayload(t.opts.Codec, version, componentEntity.Request)
+		if err != nil {
+			return "", componentError(componentEntity.Component.ID(), "", fmt.Errorf("%w: encode request failed: %w", ErrInvalidRequest, err))
+		}
+		tx.Components = append(tx.Components, &ComponentTryEntity{
+			ComponentID:  componentEntity.Component.ID(),
+			TryStatus:    TryHanging,
+			Request:      request,
+			Stage:        componentEntity.Stage,
+			DependsOn:    componentEntity.DependsOn,
+			Saga:         componentEntity.Saga,
+			Timeout:      componentEntity.Timeout,
+			BuildRequest: componentEntity.BuildRequest != nil,
+		})
+	}
+	t.opts.Tracer.Inject(ctx, tx.Metadata)
+	return detailStore.CreateTXDetail(ctx, &tx)
+}
+
+// 基于幂等键获取已有的事务
+func (t *TXManager) getTXByIdempotencyKey(ctx context.Context, key string) (*Transaction, error) {
+	_, detailOK := t.txStore.(TXDetailStore)
+	idempotentStore, ok := t.txStore.(TXIdempotentStore)
+	if !detailOK || !ok {
+		return nil, fmt.Errorf("%w: txStore does not implement TXDetailStore and TXIdempotentStore, idempotency key is not supported", ErrStoreUnsupported)
+	}
+	return idempotentStore.GetTXByIdempotencyKey(ctx, key)
+}
+
+// 等待已有事务得出结果. 事务的结果以各组件 try 请求的情况推断，不等待第二阶段执行完成
+func (t *TXManager) awaitTX(ctx context.Context, tx *Transaction) (*TXResult, error) {
+	interval := 10 * time.Millisecond
+	for {
+		txStatus := tx.Status
+		if txStatus == TXHanging || txStatus == TXDeciding {
+			txStatus = tx.getStatus(time.Now(), t.opts.Timeout)
+		}
+		if txStatus != TXHanging {
+			return txResultOf(tx, txStatus == TXSuccessful || txStatus == TXAwaitingApproval), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return txResultOf(tx, false), ctx.Err()
+		case <-time.After(interval):
+		}
+		if interval < t.opts.MonitorTick {
+			interval <<= 1
+		}
+
+		latest, err := t.txStore.GetTX(ctx, tx.TXID)
+		if err != nil {
+			return txResultOf(tx, false), err
+		}
+		tx = latest
+	}
 }
 
 func (t *TXManager) backOffTick(tick time.Duration) time.Duration {
@@ -78,6 +275,15 @@ func (t *TXManager) backOffTick(tick time.Duration) time.Duration {
 	return tick
 }
 
+// 退避等级，tick 相对 MonitorTick 翻倍的次数
+func (t *TXManager) backOffLevel(tick time.Duration) int {
+	var level int
+	for base := t.opts.MonitorTick; base < tick; base <<= 1 {
+		level++
+	}
+	return level
+}
+
 func (t *TXManager) run() {
 	var tick time.Duration
 	var err error
@@ -88,6 +294,7 @@ func (t *TXManager) run() {
 		} else {
 			tick = t.backOffTick(tick)
 		}
+		t.opts.Metrics.BackOffLevel(t.backOffLevel(tick))
 		select {
 		case <-t.ctx.Done():
 			return
@@ -95,25 +302,56 @@ func (t *TXManager) run() {
 		case <-time.After(tick):
 			// 加锁，避免多个分布式多个节点的监控任务重复执行
 			if err = t.txStore.Lock(t.ctx, t.opts.MonitorTick); err != nil {
-				// 取锁失败时（大概率被其他节点占有），不对 tick 进行退避升级
-				err = nil
+				t.opts.Metrics.LockFailed(err)
+				// 锁被其他节点占有时，不对 tick 进行退避升级. 其余错误视为 txStore 异常，需要退避
+				if errors.Is(err, ErrLockHeld) {
+					t.log(t.ctx, log.LevelDebug, "monitor lock txstore failed", log.Err(err))
+					err = nil
+				} else {
+					t.log(t.ctx, log.LevelError, "monitor lock txstore failed", log.Err(err))
+				}
 				continue
 			}
 
+			start := time.Now()
 			// 获取仍然处于 hanging 状态的事务
 			var txs []*Transaction
 			if txs, err = t.txStore.GetHangingTXs(t.ctx); err != nil {
+				t.log(t.ctx, log.LevelError, "monitor get hanging txs failed", log.Err(err))
 				_ = t.txStore.Unlock(t.ctx)
+				t.opts.Metrics.MonitorTicked(time.Since(start), err)
 				continue
 			}
+			t.opts.Metrics.HangingTXs(len(txs))
 
 			err = t.batchAdvanceProgress(txs)
 			_ = t.txStore.Unlock(t.ctx)
+			t.opts.Metrics.MonitorTicked(time.Since(start), err)
 		}
 	}
 }
 
+// 按照优先级从高到低分批推进事务，优先级相同的事务并发推进
 func (t *TXManager) batchAdvanceProgress(txs []*Transaction) error {
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Priority > txs[j].Priority
+	})
+
+	var firstErr error
+	for start := 0; start < len(txs); {
+		end := start + 1
+		for end < len(txs) && txs[end].Priority == txs[start].Priority {
+			end++
+		}
+		if err := t.concurrentAdvanceProgress(txs[start:end]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		start = end
+	}
+	return firstErr
+}
+
+func (t *TXManager) concurrentAdvanceProgress(txs []*Transaction) error {
 	// 对每笔事务进行状态推进
 	errCh := make(chan error)
 	go func() {
@@ -126,7 +364,7 @@ func (t *TXManager) batchAdvanceProgress(txs []*Transaction) error {
 			go func() {
 				defer wg.Done()
 				// 每个 goroutine 负责处理一笔事务
-				if err := t.advanceProgress(tx); err != nil {
+				if err := t.recoverProgress(tx); err != nil {
 					// 遇到错误则投递到 errCh
 					errCh <- err
 				}
@@ -149,27 +387,67 @@ func (t *TXManager) batchAdvanceProgress(txs []*Transaction) error {
 	return firstErr
 }
 
+// 轮询任务推进事务进度，对应的 span 会关联到开启事务时的原始链路
+func (t *TXManager) recoverProgress(tx *Transaction) error {
+	spanOpts := append(labelSpanOptions(tx.Labels), WithSpanLink(tx.Metadata), WithSpanAttribute(SpanAttrTXID, tx.TXID))
+	ctx, span := t.opts.Tracer.Start(t.ctx, "gotcc.recover", spanOpts...)
+	defer span.End()
+	ctx = log.WithTXID(ctx, tx.TXID)
+	_, err := t.advanceProgress(ctx, tx)
+	if err != nil {
+		span.RecordError(err)
+		t.log(ctx, log.LevelError, "monitor advance tx progress failed", log.Err(err))
+	}
+	return err
+}
+
 // 传入一个事务 id 推进其进度
-func (t *TXManager) advanceProgressByTXID(txID string) error {
+func (t *TXManager) advanceProgressByTXID(ctx context.Context, txID string) (TXStatus, error) {
 	// 获取事务日志记录
-	tx, err := t.txStore.GetTX(t.ctx, txID)
+	tx, err := t.txStore.GetTX(ctx, txID)
 	if err != nil {
-		return err
+		return TXHanging, err
 	}
-	return t.advanceProgress(tx)
+	return t.advanceProgress(ctx, tx)
 }
 
-// 传入一个事务 id 推进其进度
-func (t *TXManager) advanceProgress(tx *Transaction) error {
+// 传入一笔事务推进其进度，返回推进后事务的状态. 第二阶段未执行完成时，事务仍处于 hanging 状态，等待审批时为 awaiting-approval
+func (t *TXManager) advanceProgress(ctx context.Context, tx *Transaction) (TXStatus, error) {
 	// 根据各个 component try 请求的情况，推断出事务当前的状态
-	txStatus := tx.getStatus(time.Now().Add(-t.opts.Timeout))
-	// hanging 状态的暂时不处理
-	if txStatus == TXHanging {
-		return nil
+	now := time.Now()
+	txStatus := tx.getStatus(now, t.opts.Timeout)
+	switch {
+	// 已经记录决议的事务按照决议执行第二阶段
+	case tx.Status == TXDeciding:
+	// hanging 状态的暂时不处理. try 全部成功的暂挂事务需要等待调用方决议，定时 confirm 的事务需要等到 confirm 时间
+	case txStatus == TXHanging, txStatus == TXSuccessful && (tx.Hold || tx.ConfirmAt.After(now)):
+		return TXHanging, nil
+	// try 全部成功的审批事务进入待审批状态，等待审批或者审批超时
+	case txStatus == TXAwaitingApproval:
+		if err := t.awaitApproval(ctx, tx); err != nil {
+			return TXHanging, err
+		}
+		return TXAwaitingApproval, nil
+	}
+	// 审批、暂挂事务的超时 cancel 可能与审批或决议并发，txStore 支持时先记录决议，以先记录者为准
+	finish := t.finishTX
+	if _, ok := t.txStore.(TXDecisionStore); ok {
+		finish = t.decideTX
 	}
+	if err := finish(ctx, tx, txStatus == TXSuccessful); err != nil {
+		return TXHanging, err
+	}
+	t.opts.Metrics.TXFinished(txStatus, time.Since(tx.CreatedAt))
+	return txStatus, nil
+}
 
+// 对事务的各个组件执行第二阶段的 confirm 或者 cancel 操作，并提交事务的最终状态
+func (t *TXManager) finishTX(ctx context.Context, tx *Transaction, success bool) error {
 	// 根据事务是否成功，定制不同的处理函数
-	success := txStatus == TXSuccessful
+	phase := PhaseCancel
+	if success {
+		phase = PhaseConfirm
+	}
 	var confirmOrCancel func(ctx context.Context, component TCCComponent) (*TCCResp, error)
 	var txAdvanceProgress func(ctx context.Context) error
 	if success {
@@ -194,30 +472,209 @@ func (t *TXManager) advanceProgress(tx *Transaction) error {
 		}
 	}
 
-	for _, component := range tx.Components {
+	// confirm 按照依赖关系的拓扑序执行，cancel 按照拓扑序的逆序执行
+	for _, component := range tx.orderedComponents(!success) {
 		// 获取对应的 tcc component
-		components, err := t.registryCenter.getComponents(component.ComponentID)
-		if err != nil || len(components) == 0 {
-			return errors.New("get tcc component failed")
+		tccComponent, err := t.registryCenter.getComponent(ctx, component.ComponentID)
+		if err != nil {
+			return fmt.Errorf("get tcc component failed, err: %w", err)
 		}
 		// 执行二阶段的 confirm 或者 cancel 操作
-		resp, err := confirmOrCancel(t.ctx, components[0])
+		cctx := withComponentLogFields(ctx, component.ComponentID, phase)
+		resp, err := t.callWithRetry(cctx, tx, phase, tccComponent, confirmOrCancel)
 		if err != nil {
+			t.log(cctx, log.LevelWarn, "tx second phase failed", log.Err(err))
 			return err
 		}
 		if !resp.ACK {
-			return fmt.Errorf("component: %s ack failed", component.ComponentID)
+			t.log(cctx, log.LevelWarn, "tx second phase not acked")
+			return componentError(component.ComponentID, phase, ErrNotAcked)
 		}
 	}
 
 	// 二阶段操作都执行完成后，对事务状态进行提交
-	return txAdvanceProgress(t.ctx)
+	return txAdvanceProgress(ctx)
 }
 
-func (t *TXManager) twoPhaseCommit(ctx context.Context, txID string, componentEntities ComponentEntities) bool {
+// 先原子地记录事务的决议，再按照决议执行第二阶段. 并发的决议之间以先记录者为准，
+// 已经记录了相同决议的事务直接执行第二阶段，用于重试此前未执行完成的第二阶段
+func (t *TXManager) decideTX(ctx context.Context, tx *Transaction, success bool) error {
+	decision := TXFailure
+	if success {
+		decision = TXSuccessful
+	}
+	if tx.Status != TXDeciding {
+		decisionStore, ok := t.txStore.(TXDecisionStore)
+		if !ok {
+			return fmt.Errorf("%w: txStore does not implement TXDecisionStore", ErrStoreUnsupported)
+		}
+		if err := decisionStore.TXDecide(ctx, tx.TXID, success); err != nil {
+			return err
+		}
+		tx.Status, tx.Decision = TXDeciding, decision
+	}
+	if tx.Decision != decision {
+		return fmt.Errorf("%w: tx: %s is already decided to be %s", ErrStoreConflict, tx.TXID, tx.Decision)
+	}
+	return t.finishTX(ctx, tx, success)
+}
+
+// 决议一笔暂挂事务，commit 为 true 时 confirm，否则 cancel. 重复决议时幂等.
+// confirm 要求事务各组件的 try 均已成功并且暂挂尚未过期，决议时立即执行，不再等待定时 confirm 的时间；
+// cancel 不要求 try 已经完成，尚未创建的事务视为已经 cancel. 要求 txStore 实现 TXDecisionStore，
+// 决议先于第二阶段记录到事务日志中，与轮询任务的超时 cancel 以先记录者为准，第二阶段未执行完成时由轮询任务按照决议继续推进
+func (t *TXManager) ResolveTX(ctx context.Context, txID string, commit bool) error {
+	tx, err := t.txStore.GetTX(ctx, txID)
+	if errors.Is(err, ErrTXNotFound) && !commit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ctx = log.WithTXID(ctx, txID)
+
+	switch {
+	case tx.Status == TXSuccessful && commit, tx.Status == TXFailure && !commit:
+		return nil
+	// 已经记录了决议的，决议相同时继续执行第二阶段
+	case tx.Status == TXDeciding:
+	case tx.Status != TXHanging:
+		return fmt.Errorf("%w: tx: %s is already %s", ErrStoreConflict, txID, tx.Status)
+	case commit && tx.getStatus(time.Now(), t.opts.Timeout) != TXSuccessful:
+		return fmt.Errorf("%w: tx: %s try is not successful", ErrStoreConflict, txID)
+	}
+
+	if err = t.decideTX(ctx, tx, commit); err != nil {
+		return err
+	}
+	txStatus := TXFailure
+	if commit {
+		txStatus = TXSuccessful
+	}
+	t.opts.Metrics.TXFinished(txStatus, time.Since(tx.CreatedAt))
+	return nil
+}
+
+// 按照事务的重试策略发起第二阶段请求，直到组件确认或者达到最大尝试次数
+func (t *TXManager) callWithRetry(ctx context.Context, tx *Transaction, phase Phase, component TCCComponent, do func(ctx context.Context, component TCCComponent) (*TCCResp, error)) (*TCCResp, error) {
+	timeout := t.componentTimeout(component.ID(), phase)
+	attempts := tx.RetryPolicy.attempts()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.call(ctx, tx.TXID, phase, component, timeout, do)
+		if (err == nil && resp != nil && resp.ACK) || attempt >= attempts {
+			return resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return resp, err
+		case <-time.After(tx.RetryPolicy.backoff(attempt)):
+		}
+	}
+}
+
+// 对组件发起一次 try/confirm/cancel 请求，为其开启子 span 并上报监控指标
+func (t *TXManager) call(ctx context.Context, txID string, phase Phase, component TCCComponent, timeout time.Duration, do func(ctx context.Context, component TCCComponent) (*TCCResp, error)) (*TCCResp, error) {
+	ctx, span := t.opts.Tracer.Start(ctx, "gotcc."+phase.String(),
+		WithSpanAttribute(SpanAttrTXID, txID),
+		WithSpanAttribute(SpanAttrComponentID, component.ID()),
+		WithSpanAttribute(SpanAttrPhase, phase.String()),
+	)
+	defer span.End()
+
+	// 单次请求的时长不超过组件的超时时长，远端参与方可以通过传输协议感知到该 deadline
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := do(ctx, component)
+	ack := err == nil && resp != nil && resp.ACK
+	t.opts.Metrics.ComponentCalled(component.ID(), phase, ack, err, time.Since(start))
+	span.SetAttribute(SpanAttrACK, strconv.FormatBool(ack))
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
+}
+
+func (t *TXManager) twoPhaseCommit(ctx context.Context, txID string, componentEntities ComponentEntities, startedAt time.Time) *TXResult {
+	result := TXResult{
+		TXID:      txID,
+		Status:    TXHanging,
+		StartedAt: startedAt,
+	}
+
+	// 按照依赖关系执行 try，前序组件出现失败时，依赖于它的组件不再执行
+	tryStart := time.Now()
+	// 依赖关系已在获取组件时完成校验
+	var components []*ComponentResult
+	graph, err := componentEntities.graph()
+	if err == nil {
+		components, err = t.tryBranches(ctx, txID, graph, componentEntities, nil)
+	}
+	result.TryDuration = time.Since(tryStart)
+	result.Err, result.Components = err, components
+	// 仅当全部组件 try 成功时，事务才视为成功
+	result.Success = err == nil
+	var pending bool
+	for _, component := range components {
+		result.Success = result.Success && component.TryStatus == TrySucceesful
+		pending = pending || component.Pending
+	}
+	// 依赖于异步 try 的组件在参与方回调后续跑，截止时间之前在本节点上保留其请求参数的构造方法
+	if pending {
+		t.pendingTries.add(txID, componentEntities, tryStart.Add(componentEntities.criticalTimeout()))
+	}
+
+	// 执行二阶段. 即便第二阶段执行失败也无妨，可以通过轮询任务进行兜底处理
+	// 二阶段不受调用方 ctx 取消的影响，仅延续其链路信息
+	carrier := make(map[string]string)
+	t.opts.Tracer.Inject(ctx, carrier)
+	phase2Start := time.Now()
+	if result.Status, err = t.advanceProgressByTXID(log.WithTXID(t.opts.Tracer.Extract(t.ctx, carrier), txID), txID); err != nil {
+		t.log(ctx, log.LevelError, "advance tx progress fail", log.Err(err))
+	}
+	if result.Phase2Done = result.Status.finished(); result.Phase2Done {
+		result.Phase2Duration = time.Since(phase2Start)
+	}
+	return &result
+}
+
+// 按照依赖关系执行各组件的 try 操作. 每个组件在其依赖的组件全部 try 成功后执行，互不依赖的组件并发执行.
+// 返回各组件 try 请求的执行结果以及首个失败组件的错误，未执行、仍在执行中或者等待回调的组件处于 hanging 状态.
+// graph 为事务中全部组件的依赖关系. 续跑事务时 componentEntities 仅包含需要续跑的组件，settled 为已 try 成功的组件的响应
+func (t *TXManager) tryBranches(ctx context.Context, txID string, graph *branchGraph, componentEntities ComponentEntities, settled map[string]*TCCResp) ([]*ComponentResult, error) {
 	cctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	var (
+		mux sync.Mutex
+		// try 成功的组件的响应，用于构造依赖于它的组件的请求参数
+		acked    = make(map[string]*TCCResp, len(componentEntities))
+		outcomes = make(map[string]*ComponentResult, len(componentEntities))
+		done     = make(map[string]chan struct{}, len(componentEntities))
+	)
+	for _, componentEntity := range componentEntities {
+		done[componentEntity.Component.ID()] = make(chan struct{})
+	}
+	// 记录组件 try 请求的执行结果
+	finish := func(componentID string, status ComponentTryStatus, resp *TCCResp, err error, start time.Time) {
+		outcome := ComponentResult{
+			ComponentID: componentID,
+			TryStatus:   status,
+			Err:         err,
+			Duration:    time.Since(start),
+		}
+		if resp != nil {
+			outcome.Result, outcome.Reason, outcome.Pending = resp.Result, resp.Reason, status == TryHanging && resp.Pending
+		}
+		mux.Lock()
+		defer mux.Unlock()
+		outcomes[componentID] = &outcome
+		if status == TrySucceesful {
+			acked[componentID] = resp
+		}
+	}
+
 	// 并发执行，只要中间某次出现了失败，直接终止流程进行 cancel
 	// 如果全量执行成功，则批量执行 confirm，然后返回成功的 ack，然后
 	errCh := make(chan error, len(componentEntities))
@@ -227,29 +684,105 @@ func (t *TXManager) twoPhaseCommit(ctx context.Context, txID string, componentEn
 		for _, componentEntity := range componentEntities {
 			// shadow
 			componentEntity := componentEntity
+			componentID := componentEntity.Component.ID()
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				resp, err := componentEntity.Component.Try(cctx, &TCCReq{
-					ComponentID: componentEntity.Component.ID(),
-					TXID:        txID,
-					Data:        componentEntity.Request,
+				defer close(done[componentID])
+
+				// 等待依赖的组件执行完成，依赖的组件未 try 成功时跳过
+				deps := make(map[string]*TCCResp, len(graph.deps[componentID]))
+				for _, dep := range graph.deps[componentID] {
+					if resp, ok := settled[dep]; ok {
+						deps[dep] = resp
+						continue
+					}
+					// 续跑时依赖的组件仍在等待回调，待其回调后再续跑
+					if _, ok := done[dep]; !ok {
+						return
+					}
+					select {
+					case <-cctx.Done():
+						// 调用方取消时，尚未执行 try 的组件同样视为失败，使得事务能够立即 cancel
+						err := componentError(componentID, PhaseTry, fmt.Errorf("%w: %w", ErrTryFailed, cctx.Err()))
+						if ctx.Err() != nil {
+							uctx := context.WithoutCancel(cctx)
+							finish(componentID, TryFailure, nil, err, time.Now())
+							if _err := t.txStore.TXUpdate(uctx, txID, componentID, false); _err != nil {
+								t.log(uctx, log.LevelError, "tx updated failed", log.Err(_err))
+							}
+						}
+						errCh <- err
+						return
+					case <-done[dep]:
+					}
+					mux.Lock()
+					resp, ok := acked[dep]
+					mux.Unlock()
+					if !ok {
+						return
+					}
+					deps[dep] = resp
+				}
+
+				start := time.Now()
+				bctx := withComponentLogFields(cctx, componentID, PhaseTry)
+				request := componentEntity.Request
+				if componentEntity.BuildRequest != nil {
+					var err error
+					if request, err = componentEntity.BuildRequest(request, deps); err != nil {
+						t.log(bctx, log.LevelError, "tx build request failed", log.Err(err))
+						err = componentError(componentID, PhaseTry, fmt.Errorf("%w: build request failed: %w", ErrInvalidRequest, err))
+						finish(componentID, TryFailure, nil, err, start)
+						if _err := t.txStore.TXUpdate(bctx, txID, componentID, false); _err != nil {
+							t.log(bctx, log.LevelError, "tx updated failed", log.Err(_err))
+						}
+						errCh <- err
+						return
+					}
+				}
+
+				resp, err := t.call(bctx, txID, PhaseTry, componentEntity.Component, componentEntity.Timeout, func(ctx context.Context, component TCCComponent) (*TCCResp, error) {
+					// 把 try 请求对应的链路信息透传给组件，便于远端的参与方延续链路
+					metadata := make(map[string]string)
+					t.opts.Tracer.Inject(ctx, metadata)
+					return component.Try(ctx, &TCCReq{
+						ComponentID: component.ID(),
+						TXID:        txID,
+						Data:        request,
+						Metadata:    metadata,
+					})
 				})
+				// 异步 try 不等待参与方回调，组件保持 hanging 状态，依赖于它的组件在回调后续跑
+				if err == nil && resp.Pending {
+					t.log(bctx, log.LevelInfo, "tx try pending")
+					finish(componentID, TryHanging, resp, nil, start)
+					return
+				}
 				// 但凡有一个 component try 报错或者拒绝，都是需要进行 cancel 的，但会放在 advanceProgressByTXID 流程处理
 				if err != nil || !resp.ACK {
-					log.ErrorContextf(cctx, "tx try failed, tx id: %s, comonent id: %s, err: %v", txID, componentEntity.Component.ID(), err)
+					t.log(bctx, log.LevelError, "tx try failed", log.Err(err))
+					if err != nil {
+						resp, err = nil, componentError(componentID, PhaseTry, fmt.Errorf("%w: %w", ErrTryFailed, err))
+					} else {
+						err = componentError(componentID, PhaseTry, ErrTryRejected)
+					}
+					finish(componentID, TryFailure, resp, err, start)
 					// 对对应的事务进行更新
-					if _err := t.txStore.TXUpdate(cctx, txID, componentEntity.Component.ID(), false); _err != nil {
-						log.ErrorContextf(cctx, "tx updated failed, tx id: %s, component id: %s, err: %v", txID, componentEntity.Component.ID(), _err)
+					if _err := t.tryUpdate(bctx, txID, componentID, false, resp); _err != nil {
+						t.log(bctx, log.LevelError, "tx updated failed", log.Err(_err))
 					}
-					errCh <- fmt.Errorf("component: %s try failed", componentEntity.Component.ID())
+					errCh <- err
 					return
 				}
 				// try 请求成功，但是请求结果更新到事务日志失败时，也需要视为处理失败
-				if err = t.txStore.TXUpdate(cctx, txID, componentEntity.Component.ID(), true); err != nil {
-					log.ErrorContextf(cctx, "tx updated failed, tx id: %s, component id: %s, err: %v", txID, componentEntity.Component.ID(), err)
+				if err = t.tryUpdate(bctx, txID, componentID, true, resp); err != nil {
+					t.log(bctx, log.LevelError, "tx updated failed", log.Err(err))
+					finish(componentID, TryFailure, resp, err, start)
 					errCh <- err
+					return
 				}
+				finish(componentID, TrySucceesful, resp, nil, start)
 			}()
 		}
 
@@ -257,23 +790,60 @@ func (t *TXManager) twoPhaseCommit(ctx context.Context, txID string, componentEn
 		close(errCh)
 	}()
 
-	successful := true
-	if err := <-errCh; err != nil {
-		// 只要有一笔 try 请求出现问题，其他的都进行终止
-		cancel()
-		successful = false
+	// 只要有一笔 try 请求出现问题，其他的都进行终止
+	err := <-errCh
+
+	mux.Lock()
+	defer mux.Unlock()
+	components := make([]*ComponentResult, 0, len(componentEntities))
+	for _, componentEntity := range componentEntities {
+		outcome, ok := outcomes[componentEntity.Component.ID()]
+		if !ok {
+			outcome = &ComponentResult{
+				ComponentID: componentEntity.Component.ID(),
+				TryStatus:   TryHanging,
+			}
+		}
+		components = append(components, outcome)
 	}
+	return components, err
+}
 
-	// 执行二阶段. 即便第二阶段执行失败也无妨，可以通过轮询任务进行兜底处理
-	if err := t.advanceProgressByTXID(txID); err != nil {
-		log.ErrorContextf(ctx, "advance tx progress fail, txid: %s, err: %v", txID, err)
+// 将组件 try 请求的响应结果更新到事务日志. txStore 实现了 TXResultStore 时，一并持久化执行结果与拒绝原因.
+// 重复的回调可能并发续跑同一组件，事务日志中已记录相同的结果时视为成功
+func (t *TXManager) tryUpdate(ctx context.Context, txID, componentID string, accept bool, resp *TCCResp) error {
+	var err error
+	if resultStore, ok := t.txStore.(TXResultStore); ok && resp != nil {
+		var result *Payload
+		if resp.Result != nil {
+			if result, err = EncodePayload(t.opts.Codec, 0, resp.Result); err != nil {
+				return componentError(componentID, PhaseTry, fmt.Errorf("encode result failed: %w", err))
+			}
+		}
+		err = resultStore.TXUpdateResult(ctx, txID, componentID, accept, result, resp.Reason)
+	} else {
+		err = t.txStore.TXUpdate(ctx, txID, componentID, accept)
+	}
+	if !errors.Is(err, ErrStoreConflict) {
+		return err
 	}
-	return successful
+
+	tryStatus := TryFailure
+	if accept {
+		tryStatus = TrySucceesful
+	}
+	if tx, _err := t.txStore.GetTX(ctx, txID); _err == nil {
+		if component := tx.component(componentID); component != nil && component.TryStatus == tryStatus {
+			return nil
+		}
+	}
+	return err
 }
 
-func (t *TXManager) getComponents(ctx context.Context, reqs ...*RequestEntity) (ComponentEntities, error) {
+// 获取并校验事务的各组件. 获取成功时需要在事务执行完成后调用 release，释放组件的在途事务计数
+func (t *TXManager) getComponents(ctx context.Context, txTimeout time.Duration, reqs ...*RequestEntity) (_ ComponentEntities, _ func(), err error) {
 	if len(reqs) == 0 {
-		return nil, errors.New("emtpy task")
+		return nil, nil, ErrEmptyTransaction
 	}
 
 	// 调一下接口，确认这些都是合法的
@@ -281,28 +851,84 @@ func (t *TXManager) getComponents(ctx context.Context, reqs ...*RequestEntity) (
 	componentIDs := make([]string, 0, len(reqs))
 	for _, req := range reqs {
 		if _, ok := idToReq[req.ComponentID]; ok {
-			return nil, fmt.Errorf("repeat component: %s", req.ComponentID)
+			return nil, nil, componentError(req.ComponentID, "", ErrDuplicateComponent)
 		}
 		idToReq[req.ComponentID] = req
 		componentIDs = append(componentIDs, req.ComponentID)
 	}
 
 	// 校验其合法性
-	components, err := t.registryCenter.getComponents(componentIDs...)
+	components, release, err := t.registryCenter.getComponents(ctx, componentIDs...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	// 校验失败时直接释放
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
 	if len(componentIDs) != len(components) {
-		return nil, errors.New("invalid componentIDs ")
+		return nil, nil, fmt.Errorf("%w: invalid componentIDs", ErrComponentNotFound)
 	}
 
 	entities := make(ComponentEntities, 0, len(components))
 	for _, component := range components {
+		req := idToReq[component.ID()]
+		// 创建事务记录之前，先校验请求参数的合法性
+		if schema := t.registryCenter.schema(component.ID()); schema != nil {
+			if err := schema.Validate(req.Request); err != nil {
+				return nil, nil, componentError(component.ID(), "", fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+			}
+		}
+		if validator, ok := component.(RequestValidator); ok {
+			if err = validator.ValidateRequest(req.Request); err != nil {
+				if !errors.Is(err, ErrInvalidRequest) {
+					err = componentError(component.ID(), "", fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+				}
+				return nil, nil, err
+			}
+		}
+		// 超时时长的优先级: 请求级别 > 事务级别 > 组件注册时指定 > TXManager
+		timeout := req.Timeout
+		if timeout <= 0 {
+			timeout = txTimeout
+		}
+		if timeout <= 0 {
+			timeout = t.componentTimeout(component.ID(), PhaseTry)
+		}
 		entities = append(entities, &ComponentEntity{
-			Request:   idToReq[component.ID()].Request,
-			Component: component,
+			Request:      req.Request,
+			Component:    component,
+			Timeout:      timeout,
+			Stage:        req.Stage,
+			DependsOn:    req.DependsOn,
+			BuildRequest: req.BuildRequest,
+			Saga:         isSaga(component),
 		})
 	}
 
-	return entities, nil
+	// 校验组件之间的依赖关系，不允许依赖未参与事务的组件，也不允许存在环
+	if _, err = entities.graph(); err != nil {
+		return nil, nil, err
+	}
+
+	return entities, release, nil
+}
+
+// 获取组件指定阶段请求的超时时长，组件未指定时使用 TXManager 的 Timeout
+func (t *TXManager) componentTimeout(componentID string, phase Phase) time.Duration {
+	if timeout := t.registryCenter.timeout(componentID, phase); timeout > 0 {
+		return timeout
+	}
+	return t.opts.Timeout
+}
+
+// 把事务标签转换为 span 属性
+func labelSpanOptions(labels map[string]string) []SpanOption {
+	opts := make([]SpanOption, 0, len(labels))
+	for k, v := range labels {
+		opts = append(opts, WithSpanAttribute(SpanAttrLabelPrefix+k, v))
+	}
+	return opts
 }
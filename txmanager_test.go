@@ -27,13 +27,6 @@ func newMockTXStore() TXStore {
 
 // 创建一条事务明细记录
 func (m *mockTXStore) CreateTX(ctx context.Context, components ...TCCComponent) (string, error) {
-	txid := uuid.NewString()
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	if _, ok := m.txs[txid]; ok {
-		return "", fmt.Errorf("repeat txid: %s", txid)
-	}
-
 	componentTryEntities := make([]*ComponentTryEntity, 0, len(components))
 	for _, component := range components {
 		componentTryEntities = append(componentTryEntities, &ComponentTryEntity{
@@ -42,18 +35,51 @@ func (m *mockTXStore) CreateTX(ctx context.Context, components ...TCCComponent)
 		})
 	}
 
-	m.txs[txid] = &Transaction{
-		TXID:       txid,
+	return m.CreateTXDetail(ctx, &Transaction{
 		Status:     TXHanging,
 		CreatedAt:  time.Now(),
 		Components: componentTryEntities,
+	})
+}
+
+// 基于事务明细创建一条事务记录
+func (m *mockTXStore) CreateTXDetail(ctx context.Context, tx *Transaction) (string, error) {
+	txid := tx.TXID
+	if txid == "" {
+		txid = uuid.NewString()
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.txs[txid]; ok {
+		return "", fmt.Errorf("repeat txid: %s", txid)
+	}
+	if tx.IdempotencyKey != "" && m.getTXByIdempotencyKey(tx.IdempotencyKey) != nil {
+		return "", ErrDuplicateIdempotencyKey
 	}
 
+	tx.TXID = txid
+	m.txs[txid] = copyTX(tx)
 	return txid, nil
 }
 
+// 拷贝事务记录，避免调用方与存储模块并发读写同一份数据
+func copyTX(tx *Transaction) *Transaction {
+	copied := *tx
+	copied.Components = make([]*ComponentTryEntity, 0, len(tx.Components))
+	for _, component := range tx.Components {
+		copiedComponent := *component
+		copied.Components = append(copied.Components, &copiedComponent)
+	}
+	return &copied
+}
+
 // 更新事务进度：实际更新的是每个组件的 try 请求响应结果
 func (m *mockTXStore) TXUpdate(ctx context.Context, txID string, componentID string, accept bool) error {
+	return m.TXUpdateResult(ctx, txID, componentID, accept, nil, nil)
+}
+
+// 更新事务进度，并持久化组件 try 请求的响应结果
+func (m *mockTXStore) TXUpdateResult(ctx context.Context, txID string, componentID string, accept bool, result *Payload, reason *RejectReason) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	tx, ok := m.txs[txID]
@@ -65,13 +91,15 @@ func (m *mockTXStore) TXUpdate(ctx context.Context, txID string, componentID str
 			continue
 		}
 		if component.TryStatus != TryHanging {
-			return fmt.Errorf("invalid component status: %s, componentid: %s, txid: %s", component.TryStatus, componentID, txID)
+			return fmt.Errorf("%w: invalid component status: %s, componentid: %s, txid: %s", ErrStoreConflict, component.TryStatus, componentID, txID)
 		}
 		if accept {
 			component.TryStatus = TrySucceesful
 		} else {
 			component.TryStatus = TryFailure
 		}
+		component.Result = result
+		component.Reason = reason
 		return nil
 	}
 	return fmt.Errorf("[TXUpdate]invalid component id: %s for txid: %s", componentID, txID)
@@ -85,17 +113,16 @@ func (m *mockTXStore) TXSubmit(ctx context.Context, txID string, success bool) e
 	if !ok {
 		return fmt.Errorf("[TXSubmit]invalid txid: %s", txID)
 	}
+	txStatus := TXFailure
 	if success {
-		if tx.Status != TXHanging && tx.Status != TXSuccessful {
-			return fmt.Errorf("invalid txstatus: %s, txid: %s", tx.Status, txID)
-		}
-		tx.Status = TXSuccessful
-	} else {
-		if tx.Status != TXHanging && tx.Status != TXFailure {
-			return fmt.Errorf("invalid txstatus: %s, txid: %s", tx.Status, txID)
-		}
-		tx.Status = TXFailure
+		txStatus = TXSuccessful
+	}
+	// 已经记录决议的事务只能提交为决议的结果
+	if tx.Status == TXDeciding && tx.Decision != txStatus ||
+		tx.Status != TXHanging && tx.Status != TXAwaitingApproval && tx.Status != TXDeciding && tx.Status != txStatus {
+		return fmt.Errorf("%w: invalid txstatus: %s, txid: %s", ErrStoreConflict, tx.Status, txID)
 	}
+	tx.Status = txStatus
 	return nil
 }
 
@@ -105,23 +132,79 @@ func (m *mockTXStore) GetHangingTXs(ctx context.Context) ([]*Transaction, error)
 	defer m.mutex.Unlock()
 	var hangingTXs []*Transaction
 	for _, tx := range m.txs {
-		if tx.Status != TXHanging {
+		if tx.Status != TXHanging && tx.Status != TXAwaitingApproval && tx.Status != TXDeciding {
 			continue
 		}
-		hangingTXs = append(hangingTXs, tx)
+		hangingTXs = append(hangingTXs, copyTX(tx))
 	}
 	return hangingTXs, nil
 }
 
+// 把事务置为待审批状态
+func (m *mockTXStore) TXAwaitApproval(ctx context.Context, txID string, deadline time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	tx, ok := m.txs[txID]
+	if !ok {
+		return fmt.Errorf("[TXAwaitApproval]invalid txid: %s", txID)
+	}
+	if tx.Status != TXHanging {
+		return fmt.Errorf("%w: invalid txstatus: %s, txid: %s", ErrStoreConflict, tx.Status, txID)
+	}
+	tx.Status, tx.ApprovalDeadline = TXAwaitingApproval, deadline
+	return nil
+}
+
+// 记录事务的决议
+func (m *mockTXStore) TXDecide(ctx context.Context, txID string, success bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	tx, ok := m.txs[txID]
+	if !ok {
+		return fmt.Errorf("[TXDecide]invalid txid: %s", txID)
+	}
+	decision := TXFailure
+	if success {
+		decision = TXSuccessful
+	}
+	switch {
+	case tx.Status == TXHanging, tx.Status == TXAwaitingApproval:
+		tx.Status, tx.Decision = TXDeciding, decision
+	case tx.Status == TXDeciding && tx.Decision == decision:
+	default:
+		return fmt.Errorf("%w: invalid txstatus: %s, txid: %s", ErrStoreConflict, tx.Status, txID)
+	}
+	return nil
+}
+
 // 获取指定的一笔事务
 func (m *mockTXStore) GetTX(ctx context.Context, txID string) (*Transaction, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	tx, ok := m.txs[txID]
 	if !ok {
-		return nil, fmt.Errorf("[GetTX]invalid txid: %s", txID)
+		return nil, fmt.Errorf("%w: [GetTX]invalid txid: %s", ErrTXNotFound, txID)
+	}
+	return copyTX(tx), nil
+}
+
+// 根据幂等键获取事务
+func (m *mockTXStore) GetTXByIdempotencyKey(ctx context.Context, key string) (*Transaction, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if tx := m.getTXByIdempotencyKey(key); tx != nil {
+		return copyTX(tx), nil
 	}
-	return tx, nil
+	return nil, nil
+}
+
+func (m *mockTXStore) getTXByIdempotencyKey(key string) *Transaction {
+	for _, tx := range m.txs {
+		if tx.IdempotencyKey == key {
+			return tx
+		}
+	}
+	return nil
 }
 
 // 锁住整个 TXStore 模块
@@ -244,14 +327,14 @@ func Test_txmanager_transaction_success(t *testing.T) {
 		})
 	}
 
-	txid, ok, err := txmanager.Transaction(ctx, componentReqs...)
+	result, err := txmanager.Transaction(ctx, componentReqs)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
-	assert.Equal(t, true, ok)
-	tx, err := txmanager.txStore.GetTX(ctx, txid)
+	assert.Equal(t, true, result.Success)
+	tx, err := txmanager.txStore.GetTX(ctx, result.TXID)
 	if err != nil {
 		t.Error(err)
 		return
@@ -282,14 +365,14 @@ func Test_txmanager_transaction_fail(t *testing.T) {
 		})
 	}
 
-	txid, ok, err := txmanager.Transaction(ctx, componentReqs...)
+	result, err := txmanager.Transaction(ctx, componentReqs)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
-	assert.Equal(t, false, ok)
-	tx, err := txmanager.txStore.GetTX(ctx, txid)
+	assert.Equal(t, false, result.Success)
+	tx, err := txmanager.txStore.GetTX(ctx, result.TXID)
 	if err != nil {
 		t.Error(err)
 		return
@@ -334,13 +417,13 @@ func Test_txmanager_transaction_concurrent(t *testing.T) {
 				})
 			}
 
-			txid, ok, err := txmanager.Transaction(ctx, componentReqs...)
+			result, err := txmanager.Transaction(ctx, componentReqs)
 			if err != nil {
 				t.Error(err)
 				return
 			}
-			assert.Equal(t, true, ok)
-			tx, err := txmanager.txStore.GetTX(ctx, txid)
+			assert.Equal(t, true, result.Success)
+			tx, err := txmanager.txStore.GetTX(ctx, result.TXID)
 			if err != nil {
 				t.Error(err)
 				return
@@ -374,14 +457,14 @@ func Test_txmanager_transaction_advance_progress(t *testing.T) {
 		})
 	}
 
-	txid, ok, err := txmanager.Transaction(ctx, componentReqs...)
+	result, err := txmanager.Transaction(ctx, componentReqs)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
-	assert.Equal(t, false, ok)
-	tx, err := txmanager.txStore.GetTX(ctx, txid)
+	assert.Equal(t, false, result.Success)
+	tx, err := txmanager.txStore.GetTX(ctx, result.TXID)
 	if err != nil {
 		t.Error(err)
 		return
@@ -401,3 +484,61 @@ func Test_txManager_backOffTick(t *testing.T) {
 	got = txManager.backOffTick(got)
 	assert.Equal(t, 8*time.Second, got)
 }
+
+type sleepComponent struct {
+	TCCComponent
+	sleep time.Duration
+}
+
+// try 操作耗时 sleep，超过 ctx 的截止时间时返回错误
+func (s *sleepComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(s.sleep):
+	}
+	return s.TCCComponent.Try(ctx, req)
+}
+
+func Test_txManager_component_timeout(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore, WithTimeout(50*time.Millisecond))
+	defer txManager.Stop()
+
+	// 组件注册时指定的超时时长优先于 TXManager 的 Timeout
+	assert.Equal(t, nil, txManager.Register(&sleepComponent{TCCComponent: newMockComponent("slow"), sleep: 100 * time.Millisecond}, WithTryTimeout(time.Second)))
+	assert.Equal(t, nil, txManager.Register(newMockComponent("fast")))
+	ctx := context.Background()
+
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "slow"}, {ComponentID: "fast"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXSuccessful, tx.Status)
+	// 并发执行的组件之间，事务的截止时间以耗时最长的组件为准
+	assert.Equal(t, time.Second, tx.Deadline.Sub(tx.CreatedAt))
+
+	// 请求级别的超时时长优先于组件注册时的超时时长
+	result, err = txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "slow", Timeout: 20 * time.Millisecond}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, result.Success)
+	tx, err = txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXFailure, tx.Status)
+}
+
+func Test_transaction_getStatus(t *testing.T) {
+	now := time.Now()
+	tx := Transaction{
+		CreatedAt:  now.Add(-time.Minute),
+		Components: []*ComponentTryEntity{{ComponentID: "a", TryStatus: TryHanging}},
+	}
+	// 未持久化截止时间时，以 CreatedAt 加上 timeout 作为截止时间
+	assert.Equal(t, TXFailure, tx.getStatus(now, time.Second))
+	assert.Equal(t, TXHanging, tx.getStatus(now, time.Hour))
+
+	tx.Deadline = now.Add(time.Second)
+	assert.Equal(t, TXHanging, tx.getStatus(now, time.Second))
+	assert.Equal(t, TXFailure, tx.getStatus(now.Add(2*time.Second), time.Second))
+}
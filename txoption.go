@@ -0,0 +1,141 @@
+package gotcc
+
+import (
+	"time"
+)
+
+// 单笔事务的参数，会随事务记录一并持久化，轮询任务推进事务时同样遵循.
+// 指定任一参数都要求 txStore 实现 TXDetailStore，否则开启事务时报错
+type TXOptions struct {
+	// 事务的超时时长，作为各组件 try 请求的默认超时时长. 未指定时使用组件或 TXManager 的超时时长
+	Timeout time.Duration
+	// 事务的标签，用于检索与观测
+	Labels map[string]string
+	// 幂等键. 相同幂等键的重复请求不会创建新的事务，而是返回已有事务的 id 和结果，
+	// 已有事务仍在执行中时等待其结果. 要求 txStore 实现 TXIdempotentStore
+	IdempotencyKey string
+	// 第二阶段 confirm/cancel 请求的重试策略
+	RetryPolicy *RetryPolicy
+	// 事务的优先级，轮询任务优先推进优先级高的事务
+	Priority int
+	// 调用方指定的事务 id，要求 txStore 实现 TXDetailStore
+	TXID string
+	// try 全部成功后暂不 confirm，等待通过 ResolveTX 决议. 要求 txStore 实现 TXDetailStore 与 TXDecisionStore
+	Hold bool
+	// 审批时长. 大于 0 时 try 全部成功后进入待审批状态，通过 ApproveTX 审批后才会 confirm，
+	// 超过审批时长仍未审批时自动 cancel. 要求 txStore 实现 TXDetailStore、TXApprovalStore 与 TXDecisionStore
+	ApprovalTimeout time.Duration
+	// 定时 confirm 的时间. try 全部成功后等到该时间才由轮询任务 confirm，要求 txStore 实现 TXDetailStore
+	ConfirmAt time.Time
+	// 暂挂的过期时间，超过该时间仍未决议时自动 cancel. 仅对暂挂事务生效
+	HoldExpireAt time.Time
+}
+
+// 是否指定了需要随事务记录持久化的参数
+func (o *TXOptions) persistent() bool {
+	return o.Timeout > 0 || len(o.Labels) > 0 || o.IdempotencyKey != "" || o.RetryPolicy != nil || o.Priority != 0 ||
+		o.TXID != "" || o.Hold || o.ApprovalTimeout > 0 || !o.ConfirmAt.IsZero() || !o.HoldExpireAt.IsZero()
+}
+
+type TXOption func(*TXOptions)
+
+func WithTXTimeout(timeout time.Duration) TXOption {
+	return func(o *TXOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithLabels(labels map[string]string) TXOption {
+	return func(o *TXOptions) {
+		if o.Labels == nil {
+			o.Labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			o.Labels[k] = v
+		}
+	}
+}
+
+func WithIdempotencyKey(key string) TXOption {
+	return func(o *TXOptions) {
+		o.IdempotencyKey = key
+	}
+}
+
+func WithRetryPolicy(policy *RetryPolicy) TXOption {
+	return func(o *TXOptions) {
+		o.RetryPolicy = policy
+	}
+}
+
+func WithPriority(priority int) TXOption {
+	return func(o *TXOptions) {
+		o.Priority = priority
+	}
+}
+
+func WithTXID(txID string) TXOption {
+	return func(o *TXOptions) {
+		o.TXID = txID
+	}
+}
+
+// try 全部成功后暂不 confirm，由调用方通过 TXManager.ResolveTX 决议 confirm 或 cancel.
+// 轮询任务不会推进 try 已全部成功的暂挂事务，try 失败的暂挂事务仍然会被 cancel
+func WithHold() TXOption {
+	return func(o *TXOptions) {
+		o.Hold = true
+	}
+}
+
+// try 全部成功后进入待审批状态，由审批人通过 TXManager.ApproveTX 审批通过后 confirm，驳回或者审批超时后 cancel
+func WithApproval(timeout time.Duration) TXOption {
+	return func(o *TXOptions) {
+		o.ApprovalTimeout = timeout
+	}
+}
+
+// try 全部成功后等到 confirmAt 才 confirm，例如在结算时间统一 confirm. confirm 由轮询任务推进，
+// 实际的 confirm 时间最多晚于 confirmAt 一个轮询周期. confirmAt 早于 try 完成的时间时立即 confirm
+func WithConfirmAt(confirmAt time.Time) TXOption {
+	return func(o *TXOptions) {
+		o.ConfirmAt = confirmAt
+	}
+}
+
+// 暂挂事务，直到外部条件满足后由调用方通过 TXManager.ResolveTX 决议. 超过 expireAt 仍未决议时由轮询任务自动 cancel
+func WithHoldUntil(expireAt time.Time) TXOption {
+	return func(o *TXOptions) {
+		o.Hold = true
+		o.HoldExpireAt = expireAt
+	}
+}
+
+// 第二阶段请求的重试策略. 单次推进事务时，失败的 confirm/cancel 请求按照退避间隔重试
+type RetryPolicy struct {
+	// 最大尝试次数，小于等于 1 时不重试
+	MaxAttempts int `json:"maxAttempts"`
+	// 首次重试的间隔时长，后续每次翻倍
+	Backoff time.Duration `json:"backoff"`
+	// 重试间隔时长的上限，小于等于 0 时不限制
+	MaxBackoff time.Duration `json:"maxBackoff,omitempty"`
+}
+
+// 第 attempt 次重试前的等待时长
+func (r *RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := r.Backoff
+	for i := 1; i < attempt; i++ {
+		backoff <<= 1
+		if r.MaxBackoff > 0 && backoff >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+func (r *RetryPolicy) attempts() int {
+	if r == nil || r.MaxAttempts <= 1 {
+		return 1
+	}
+	return r.MaxAttempts
+}
@@ -0,0 +1,213 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 仅实现基础 TXStore 接口的事务日志存储模块
+type baseTXStore struct {
+	TXStore
+}
+
+// confirm 请求前 failures 次不确认，cancel 请求记录调用顺序
+type flakyComponent struct {
+	TCCComponent
+	mutex    sync.Mutex
+	failures int
+	canceled *[]string
+}
+
+func (f *flakyComponent) Confirm(ctx context.Context, txID string) (*TCCResp, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return &TCCResp{ComponentID: f.ID(), TXID: txID}, nil
+	}
+	return f.TCCComponent.Confirm(ctx, txID)
+}
+
+func (f *flakyComponent) Cancel(ctx context.Context, txID string) (*TCCResp, error) {
+	f.mutex.Lock()
+	*f.canceled = append(*f.canceled, txID)
+	f.mutex.Unlock()
+	return f.TCCComponent.Cancel(ctx, txID)
+}
+
+func Test_txManager_transaction_options(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore)
+	defer txManager.Stop()
+	var canceled []string
+	assert.Equal(t, nil, txManager.Register(&flakyComponent{TCCComponent: newMockComponent("a"), failures: 2, canceled: &canceled}))
+	ctx := context.Background()
+
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}},
+		WithTXID("custom"),
+		WithTXTimeout(time.Minute),
+		WithLabels(map[string]string{"biz": "order"}),
+		WithIdempotencyKey("key"),
+		WithRetryPolicy(&policy),
+		WithPriority(2),
+	)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, "custom", result.TXID)
+
+	// 单笔事务的参数随事务记录一并持久化，第二阶段按照重试策略重试后完成
+	tx, err := txManager.GetTX(ctx, result.TXID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TXSuccessful, tx.Status)
+	assert.Equal(t, time.Minute, tx.Deadline.Sub(tx.CreatedAt))
+	assert.Equal(t, map[string]string{"biz": "order"}, tx.Labels)
+	assert.Equal(t, "key", tx.IdempotencyKey)
+	assert.Equal(t, &policy, tx.RetryPolicy)
+	assert.Equal(t, 2, tx.Priority)
+
+	// 事务 id 重复时报错
+	_, err = txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithTXID("custom"))
+	assert.NotEqual(t, nil, err)
+
+	// 基础的 TXStore 无法持久化单笔事务的参数，任一参数都会报错而不是被静默丢弃
+	baseManager := NewTXManager(&baseTXStore{TXStore: newMockTXStore()})
+	defer baseManager.Stop()
+	assert.Equal(t, nil, baseManager.Register(newMockComponent("a")))
+	for _, opt := range []TXOption{
+		WithTXID("custom"),
+		WithTXTimeout(time.Minute),
+		WithLabels(map[string]string{"biz": "order"}),
+		WithRetryPolicy(&policy),
+		WithPriority(2),
+	} {
+		_, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, opt)
+		assert.Equal(t, true, errors.Is(err, ErrStoreUnsupported))
+	}
+	// 执行阶段、依赖关系以及 saga 分支同样无法持久化
+	assert.Equal(t, nil, baseManager.Register(newMockComponent("b")))
+	assert.Equal(t, nil, baseManager.Register(NewSagaComponent(&mockSagaComponent{id: "saga", recorder: &recorder{}})))
+	for _, reqs := range [][]*RequestEntity{
+		{{ComponentID: "a", Stage: 1}},
+		{{ComponentID: "a"}, {ComponentID: "b", DependsOn: []string{"a"}}},
+		{{ComponentID: "a", BuildRequest: func(request map[string]interface{}, deps map[string]*TCCResp) (map[string]interface{}, error) {
+			return request, nil
+		}}},
+		{{ComponentID: "a"}, {ComponentID: "saga"}},
+	} {
+		_, err = baseManager.Transaction(ctx, reqs)
+		assert.Equal(t, true, errors.Is(err, ErrStoreUnsupported))
+	}
+	// 无法持久化截止时间时，try 超时时长不能超过 TXManager 的 Timeout
+	_, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a", Timeout: time.Hour}})
+	assert.Equal(t, true, errors.Is(err, ErrStoreUnsupported))
+	// 未指定参数时正常执行
+	result, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+}
+
+func Test_txManager_priority(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore, WithMonitorTick(time.Hour))
+	defer txManager.Stop()
+	var canceled []string
+	assert.Equal(t, nil, txManager.Register(&flakyComponent{TCCComponent: newMockComponent("a"), canceled: &canceled}))
+
+	// 构造 try 失败的事务，轮询任务按照优先级从高到低推进
+	var txs []*Transaction
+	for i, priority := range []int{0, 5, 1} {
+		tx := Transaction{
+			TXID:       []string{"low", "high", "middle"}[i],
+			Status:     TXHanging,
+			CreatedAt:  time.Now(),
+			Components: []*ComponentTryEntity{{ComponentID: "a", TryStatus: TryFailure}},
+			Priority:   priority,
+		}
+		_, err := txStore.(TXDetailStore).CreateTXDetail(context.Background(), &tx)
+		assert.Equal(t, nil, err)
+		txs = append(txs, &tx)
+	}
+	assert.Equal(t, nil, txManager.batchAdvanceProgress(txs))
+	assert.Equal(t, []string{"high", "middle", "low"}, canceled)
+}
+
+func Test_RetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 3 * time.Second}
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 3*time.Second, policy.backoff(3))
+	assert.Equal(t, 5, policy.attempts())
+
+	var nilPolicy *RetryPolicy
+	assert.Equal(t, 1, nilPolicy.attempts())
+}
+
+// 记录 try 请求次数
+type countComponent struct {
+	TCCComponent
+	mutex sync.Mutex
+	tries int
+}
+
+func (c *countComponent) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	c.mutex.Lock()
+	c.tries++
+	c.mutex.Unlock()
+	<-time.After(50 * time.Millisecond)
+	return c.TCCComponent.Try(ctx, req)
+}
+
+func (c *countComponent) triesCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.tries
+}
+
+func Test_txManager_idempotency_key(t *testing.T) {
+	txManager := NewTXManager(newMockTXStore())
+	defer txManager.Stop()
+	component := countComponent{TCCComponent: newMockComponent("a")}
+	assert.Equal(t, nil, txManager.Register(&component))
+	ctx := context.Background()
+
+	// 并发的重复请求只会创建一笔事务，并且都能拿到该事务的结果
+	var wg sync.WaitGroup
+	txIDs := make([]string, 5)
+	for i := range txIDs {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithIdempotencyKey("key"))
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, result.Success)
+			txIDs[i] = result.TXID
+		}()
+	}
+	wg.Wait()
+
+	for _, txID := range txIDs {
+		assert.Equal(t, txIDs[0], txID)
+	}
+	assert.Equal(t, 1, component.triesCount())
+
+	// 事务完成后的重复请求直接返回结果
+	result, err := txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithIdempotencyKey("key"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, txIDs[0], result.TXID)
+	assert.Equal(t, 1, component.triesCount())
+
+	// 基础的 TXStore 不支持幂等键
+	baseManager := NewTXManager(&baseTXStore{TXStore: newMockTXStore()})
+	defer baseManager.Stop()
+	assert.Equal(t, nil, baseManager.Register(newMockComponent("a")))
+	_, err = baseManager.Transaction(ctx, []*RequestEntity{{ComponentID: "a"}}, WithIdempotencyKey("key"))
+	assert.NotEqual(t, nil, err)
+}
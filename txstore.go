@@ -9,16 +9,58 @@ import (
 type TXStore interface {
 	// 创建一条事务明细记录
 	CreateTX(ctx context.Context, components ...TCCComponent) (txID string, err error)
-	// 更新事务进度：实际更新的是每个组件的 try 请求响应结果
+	// 更新事务进度：实际更新的是每个组件的 try 请求响应结果. 组件 try 状态已不是 hanging 时需要返回包装了 ErrStoreConflict 的错误
 	TXUpdate(ctx context.Context, txID string, componentID string, accept bool) error
-	// 提交事务的最终状态, 标识事务执行结果为成功或失败
+	// 提交事务的最终状态, 标识事务执行结果为成功或失败. 事务已被提交为相反的状态时需要返回包装了 ErrStoreConflict 的错误
 	TXSubmit(ctx context.Context, txID string, success bool) error
 	// 获取到所有未完成的事务
 	GetHangingTXs(ctx context.Context) ([]*Transaction, error)
-	// 获取指定的一笔事务
+	// 获取指定的一笔事务. 事务不存在时需要返回包装了 ErrTXNotFound 的错误
 	GetTX(ctx context.Context, txID string) (*Transaction, error)
-	// 锁住整个 TXStore 模块（要求为分布式锁）
+	// 锁住整个 TXStore 模块（要求为分布式锁）. 锁被其他节点持有时需要返回包装了 ErrLockHeld 的错误
 	Lock(ctx context.Context, expireDuration time.Duration) error
 	// 解锁TXStore 模块
 	Unlock(ctx context.Context) error
 }
+
+// 可选实现的扩展能力：基于完整的事务明细创建事务记录.
+// TXStore 同时实现了该接口时，TXManager 会优先通过 CreateTXDetail 创建事务，
+// 从而将链路信息、请求参数以及单笔事务的参数等附加字段一并持久化到事务日志中
+type TXDetailStore interface {
+	// 基于事务明细创建一条事务记录，并取得全局唯一的事务 id.
+	// tx.TXID 非空时使用调用方指定的事务 id，该 id 已存在时需要返回错误
+	CreateTXDetail(ctx context.Context, tx *Transaction) (txID string, err error)
+}
+
+// 可选实现的扩展能力：更新事务进度的同时持久化组件 try 请求的响应结果.
+// TXStore 同时实现了该接口时，TXManager 会通过 TXUpdateResult 代替 TXUpdate 更新事务进度
+type TXResultStore interface {
+	// 更新组件 try 请求的响应结果，result 为 try 的执行结果，reason 为拒绝原因，均可能为空
+	TXUpdateResult(ctx context.Context, txID string, componentID string, accept bool, result *Payload, reason *RejectReason) error
+}
+
+// 可选实现的扩展能力：基于幂等键检索事务.
+// 使用幂等键开启事务时要求 txStore 同时实现 TXDetailStore 与该接口，
+// 并且在 CreateTXDetail 时保证幂等键的唯一性
+type TXIdempotentStore interface {
+	// 根据幂等键获取事务，不存在时返回 nil
+	GetTXByIdempotencyKey(ctx context.Context, key string) (*Transaction, error)
+}
+
+// 可选实现的扩展能力：人工审批.
+// 开启需要审批的事务时要求 txStore 同时实现 TXDetailStore、TXDecisionStore 与该接口. 处于 awaiting-approval 状态的事务同样属于未完成的事务，
+// GetHangingTXs 需要一并返回，TXSubmit 需要支持将其提交为成功或失败
+type TXApprovalStore interface {
+	// 把 hanging 状态的事务置为 awaiting-approval 状态，并记录审批截止时间
+	TXAwaitApproval(ctx context.Context, txID string, deadline time.Time) error
+}
+
+// 可选实现的扩展能力：原子地记录事务的决议.
+// 开启需要审批或者暂挂的事务，以及通过 ResolveTX 决议事务时要求 txStore 实现该接口. 审批、决议与轮询任务推进事务时均先记录决议再执行第二阶段，
+// 以先记录者为准，轮询任务随后按照已记录的决议推进. 处于 deciding 状态的事务同样属于未完成的事务，
+// GetHangingTXs 需要一并返回，TXSubmit 需要支持将其提交为决议的结果
+type TXDecisionStore interface {
+	// 把 hanging 或者 awaiting-approval 状态的事务置为 deciding 状态，并记录决议结果，success 为 true 时 confirm.
+	// 事务已处于 deciding 状态并且决议相同时视为成功，其余情况返回 ErrStoreConflict
+	TXDecide(ctx context.Context, txID string, success bool) error
+}
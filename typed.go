@@ -0,0 +1,156 @@
+package gotcc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// 强类型的 tcc 请求参数
+type TypedTCCReq[Req any] struct {
+	ComponentID string
+	TXID        string
+	Data        *Req
+	// 透传给组件的元数据，例如链路追踪信息
+	Metadata map[string]string
+}
+
+// 强类型的 tcc 组件，请求参数在 try 之前已经完成解码和校验.
+// 通过 NewTypedComponent 转换为 TCCComponent 后注册到 TXManager
+type TypedComponent[Req any] interface {
+	// 返回组件唯一 id
+	ID() string
+	// 执行第一阶段的 try 操作
+	Try(ctx context.Context, req *TypedTCCReq[Req]) (*TCCResp, error)
+	// 执行第二阶段的 confirm 操作
+	Confirm(ctx context.Context, txID string) (*TCCResp, error)
+	// 执行第二阶段的 cancel 操作
+	Cancel(ctx context.Context, txID string) (*TCCResp, error)
+}
+
+// 请求参数与 map 形式之间的编解码
+type RequestCodec[Req any] interface {
+	Decode(request map[string]interface{}) (*Req, error)
+	Encode(req *Req) (map[string]interface{}, error)
+}
+
+// 请求参数自身的校验逻辑，解码后执行
+type Validator interface {
+	Validate() error
+}
+
+// 可选实现的扩展能力：在创建事务记录之前校验组件的请求参数.
+// 组件实现了该接口时，非法的请求参数会在 TXManager 获取组件时直接报错，不会产生事务记录
+type RequestValidator interface {
+	ValidateRequest(request map[string]interface{}) error
+}
+
+// 基于 json 的请求参数编解码
+type JSONRequestCodec[Req any] struct {
+	// 是否拒绝请求参数中出现未定义的字段
+	DisallowUnknownFields bool
+}
+
+func (j JSONRequestCodec[Req]) Decode(request map[string]interface{}) (*Req, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if j.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	var req Req
+	if err = decoder.Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (j JSONRequestCodec[Req]) Encode(req *Req) (map[string]interface{}, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var request map[string]interface{}
+	if err = json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// 把强类型组件转换为 TCCComponent. codec 为空时使用 JSONRequestCodec
+func NewTypedComponent[Req any](component TypedComponent[Req], codec RequestCodec[Req]) TCCComponent {
+	if codec == nil {
+		codec = JSONRequestCodec[Req]{}
+	}
+	return &typedComponent[Req]{
+		component: component,
+		codec:     codec,
+	}
+}
+
+// 基于强类型的请求参数构造 RequestEntity. codec 为空时使用 JSONRequestCodec
+func NewRequestEntity[Req any](componentID string, req *Req, codec RequestCodec[Req]) (*RequestEntity, error) {
+	if codec == nil {
+		codec = JSONRequestCodec[Req]{}
+	}
+	request, err := codec.Encode(req)
+	if err != nil {
+		return nil, componentError(componentID, "", fmt.Errorf("%w: encode request failed: %w", ErrInvalidRequest, err))
+	}
+	return &RequestEntity{
+		ComponentID: componentID,
+		Request:     request,
+	}, nil
+}
+
+type typedComponent[Req any] struct {
+	component TypedComponent[Req]
+	codec     RequestCodec[Req]
+}
+
+func (t *typedComponent[Req]) ID() string {
+	return t.component.ID()
+}
+
+func (t *typedComponent[Req]) ValidateRequest(request map[string]interface{}) error {
+	_, err := t.decode(request)
+	return err
+}
+
+func (t *typedComponent[Req]) Try(ctx context.Context, req *TCCReq) (*TCCResp, error) {
+	data, err := t.decode(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	return t.component.Try(ctx, &TypedTCCReq[Req]{
+		ComponentID: req.ComponentID,
+		TXID:        req.TXID,
+		Data:        data,
+		Metadata:    req.Metadata,
+	})
+}
+
+func (t *typedComponent[Req]) Confirm(ctx context.Context, txID string) (*TCCResp, error) {
+	return t.component.Confirm(ctx, txID)
+}
+
+func (t *typedComponent[Req]) Cancel(ctx context.Context, txID string) (*TCCResp, error) {
+	return t.component.Cancel(ctx, txID)
+}
+
+// 解码请求参数，请求参数实现了 Validator 时一并校验
+func (t *typedComponent[Req]) decode(request map[string]interface{}) (*Req, error) {
+	req, err := t.codec.Decode(request)
+	if err != nil {
+		return nil, componentError(t.component.ID(), "", fmt.Errorf("%w: decode request failed: %w", ErrInvalidRequest, err))
+	}
+	if validator, ok := interface{}(req).(Validator); ok {
+		if err = validator.Validate(); err != nil {
+			return nil, componentError(t.component.ID(), "", fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+		}
+	}
+	return req, nil
+}
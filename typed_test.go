@@ -0,0 +1,73 @@
+package gotcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type transferReq struct {
+	BizID  string `json:"biz_id"`
+	Amount int    `json:"amount"`
+}
+
+func (t *transferReq) Validate() error {
+	if t.BizID == "" {
+		return errors.New("empty biz_id")
+	}
+	return nil
+}
+
+type transferComponent struct {
+	id   string
+	reqs []*transferReq
+}
+
+func (t *transferComponent) ID() string {
+	return t.id
+}
+
+func (t *transferComponent) Try(ctx context.Context, req *TypedTCCReq[transferReq]) (*TCCResp, error) {
+	t.reqs = append(t.reqs, req.Data)
+	return &TCCResp{ComponentID: t.id, TXID: req.TXID, ACK: true}, nil
+}
+
+func (t *transferComponent) Confirm(ctx context.Context, txID string) (*TCCResp, error) {
+	return &TCCResp{ComponentID: t.id, TXID: txID, ACK: true}, nil
+}
+
+func (t *transferComponent) Cancel(ctx context.Context, txID string) (*TCCResp, error) {
+	return &TCCResp{ComponentID: t.id, TXID: txID, ACK: true}, nil
+}
+
+func Test_TypedComponent(t *testing.T) {
+	txStore := newMockTXStore()
+	txManager := NewTXManager(txStore)
+	defer txManager.Stop()
+
+	component := transferComponent{id: "transfer"}
+	assert.Equal(t, nil, txManager.Register(NewTypedComponent[transferReq](&component, JSONRequestCodec[transferReq]{DisallowUnknownFields: true})))
+	ctx := context.Background()
+
+	req, err := NewRequestEntity("transfer", &transferReq{BizID: "biz", Amount: 10}, nil)
+	assert.Equal(t, nil, err)
+	result, err := txManager.Transaction(ctx, []*RequestEntity{req})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, result.Success)
+	assert.Equal(t, []*transferReq{{BizID: "biz", Amount: 10}}, component.reqs)
+
+	// 非法的请求参数在创建事务记录之前即被拒绝
+	tests := []map[string]interface{}{
+		{"biz_id": "biz", "amount": "10"},
+		{"amount": 10},
+		{"biz_id": "biz", "unknown": true},
+	}
+	for _, request := range tests {
+		_, err = txManager.Transaction(ctx, []*RequestEntity{{ComponentID: "transfer", Request: request}})
+		assert.NotEqual(t, nil, err)
+	}
+	assert.Equal(t, 1, len(txStore.(*mockTXStore).txs))
+	assert.Equal(t, 1, len(component.reqs))
+}